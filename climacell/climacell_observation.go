@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"text/template"
 	"time"
+
+	"github.com/gigawhitlocks/weather/geocoding"
 )
 
 type ClimaCellObservation struct {
@@ -77,6 +79,126 @@ type ClimaCellObservation struct {
 	WeatherCode struct {
 		Value string `json:"value"`
 	} `json:"weather_code"`
+
+	PM25 struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"pm25"`
+	PM10 struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"pm10"`
+	O3 struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"o3"`
+	NO2 struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"no2"`
+	CO struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"co"`
+	SO2 struct {
+		Value float64 `json:"value"`
+		Units string  `json:"units"`
+	} `json:"so2"`
+	EPAAQI struct {
+		Value float64 `json:"value"`
+	} `json:"epa_aqi"`
+	EPAHealthConcern struct {
+		Value string `json:"value"`
+	} `json:"epa_health_concern"`
+	PollenTree struct {
+		Value string `json:"value"`
+	} `json:"pollen_tree"`
+	PollenGrass struct {
+		Value string `json:"value"`
+	} `json:"pollen_grass"`
+	PollenWeed struct {
+		Value string `json:"value"`
+	} `json:"pollen_weed"`
+
+	// Code is the Tomorrow.io weather code behind WeatherCode.
+	Code WeatherCode `json:"-"`
+}
+
+// newObservation fills an observation at coords from a Tomorrow.io interval
+// in imperial units.
+func newObservation(coords *geocoding.Coordinates, i *Interval) *ClimaCellObservation {
+	v := &i.Values
+	c := &ClimaCellObservation{Lat: coords.Latitude, Lon: coords.Longitude, Code: v.WeatherCode}
+	c.Temp.Value, c.Temp.Units = v.Temperature, "F"
+	c.FeelsLike.Value, c.FeelsLike.Units = v.TemperatureApparent, "F"
+	c.Dewpoint.Value, c.Dewpoint.Units = v.DewPoint, "F"
+	c.WindGust.Value, c.WindGust.Units = v.WindGust, "mph"
+	c.BaroPressure.Value, c.BaroPressure.Units = v.PressureSurfaceLevel, "inHg"
+	c.Visibility.Value, c.Visibility.Units = v.Visibility, "mi"
+	c.Precipitation.Value, c.Precipitation.Units = v.PrecipitationIntensity, "in/hr"
+	c.CloudCover.Value, c.CloudCover.Units = v.CloudCover, "%"
+	c.Humidity.Value, c.Humidity.Units = v.Humidity, "%"
+	c.WindDirection.Value, c.WindDirection.Units = v.WindDirection, "degrees"
+	c.CloudCeiling.Units, c.CloudBase.Units = "mi", "mi"
+	if v.CloudCeiling != nil {
+		c.CloudCeiling.Value = *v.CloudCeiling
+	}
+	if v.CloudBase != nil {
+		c.CloudBase.Value = *v.CloudBase
+	}
+	c.PrecipitationType.Value = v.PrecipitationType.String()
+	c.ObservationTime.Value = i.StartTime
+	c.WeatherCode.Value = v.WeatherCode.Key()
+
+	setMeasurement(&c.PM25.Value, &c.PM25.Units, v.ParticulateMatter25, "µg/m³")
+	setMeasurement(&c.PM10.Value, &c.PM10.Units, v.ParticulateMatter10, "µg/m³")
+	setMeasurement(&c.O3.Value, &c.O3.Units, v.PollutantO3, "ppb")
+	setMeasurement(&c.NO2.Value, &c.NO2.Units, v.PollutantNO2, "ppb")
+	setMeasurement(&c.CO.Value, &c.CO.Units, v.PollutantCO, "ppm")
+	setMeasurement(&c.SO2.Value, &c.SO2.Units, v.PollutantSO2, "ppb")
+	if v.EPAIndex != nil {
+		c.EPAAQI.Value = *v.EPAIndex
+	}
+	c.EPAHealthConcern.Value = describe(healthConcerns, v.EPAHealthConcern)
+	c.PollenTree.Value = describe(pollenLevels, v.TreeIndex)
+	c.PollenGrass.Value = describe(pollenLevels, v.GrassIndex)
+	c.PollenWeed.Value = describe(pollenLevels, v.WeedIndex)
+	return c
+}
+
+// setMeasurement sets value and units from v, if it was reported.
+func setMeasurement(value *float64, units *string, v *float64, u string) {
+	if v == nil {
+		return
+	}
+	*value, *units = *v, u
+}
+
+var healthConcerns = []string{"Good", "Moderate", "Unhealthy for Sensitive Groups", "Unhealthy", "Very Unhealthy", "Hazardous"}
+
+var pollenLevels = []string{"None", "Very Low", "Low", "Medium", "High", "Very High"}
+
+// describe returns the description of level, or "" if it wasn't reported.
+func describe(descriptions []string, level *int) string {
+	if level == nil {
+		return ""
+	}
+	if *level < 0 || *level >= len(descriptions) {
+		return fmt.Sprintf("Unknown (%d)", *level)
+	}
+	return descriptions[*level]
+}
+
+// localizeTimes converts the observation's times, which the API reports in
+// UTC, to the time zone at coords.
+func (c *ClimaCellObservation) localizeTimes(coords *geocoding.Coordinates) {
+	loc, err := coords.TimeZone()
+	if err != nil {
+		return
+	}
+	c.ObservationTime.Value = c.ObservationTime.Value.In(loc)
+	c.Sunrise.Value = c.Sunrise.Value.In(loc)
+	c.Sunset.Value = c.Sunset.Value.In(loc)
 }
 
 func (c *ClimaCellObservation) String() string {
@@ -87,7 +209,15 @@ func (c *ClimaCellObservation) String() string {
 | Temperature | {{.Temp.Value}} °{{.Temp.Units}} | Feels Like | {{.FeelsLike.Value}} °{{.FeelsLike.Units}} |{{if (ne .Precipitation.Value 0.0)}}
 | Precipitation | {{.Precipitation.Value}} {{.Precipitation.Units}} | Type of Precipitation | {{.PrecipitationType.Value }} |{{end}}
 | Wind Gust | {{.WindGust.Value}} {{.WindGust.Units}} | Barometric Pressure | {{.BaroPressure.Value}} {{.BaroPressure.Units}} |
-| Humidity | {{.Humidity.Value}}{{.Humidity.Units}} | Cloud Cover | {{.CloudCover.Value}}{{.CloudCover.Units}} |
+| Humidity | {{.Humidity.Value}}{{.Humidity.Units}} | Cloud Cover | {{.CloudCover.Value}}{{.CloudCover.Units}} |{{if .EPAHealthConcern.Value}}
+| Air Quality | {{.EPAAQI.Value}} AQI, {{.EPAHealthConcern.Value}} | Ozone | {{.O3.Value}} {{.O3.Units}} |{{end}}{{if .PM25.Units}}
+| PM2.5 | {{.PM25.Value}} {{.PM25.Units}} | PM10 | {{.PM10.Value}} {{.PM10.Units}} |{{end}}{{if .NO2.Units}}
+| NO2 | {{.NO2.Value}} {{.NO2.Units}} | CO | {{.CO.Value}} {{.CO.Units}} |{{end}}{{if .SO2.Units}}
+| SO2 | {{.SO2.Value}} {{.SO2.Units}} | | |{{end}}{{if .PollenTree.Value}}
+| Tree Pollen | {{.PollenTree.Value}} | Grass Pollen | {{.PollenGrass.Value}} |
+| Weed Pollen | {{.PollenWeed.Value}} | | |{{end}}{{if not .Sunrise.Value.IsZero}}
+| Sunrise | {{.Sunrise.Value.Format "3:04 PM MST"}} | Sunset | {{.Sunset.Value.Format "3:04 PM MST"}} |{{end}}{{if not .ObservationTime.Value.IsZero}}
+| Observed | {{.ObservationTime.Value.Format "Mon Jan 2 3:04 PM MST"}} | | |{{end}}
 `)
 	buffer := new(bytes.Buffer)
 	err := t.Execute(buffer, c)
@@ -1,12 +1,21 @@
 package climacell
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/mosaic"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func testIsValidFeature(t *testing.T) {
+func TestIsValidFeature(t *testing.T) {
 	assert.True(t, isValidFeature("temp"))
 	assert.True(t, isValidFeature("precipitation"))
 	assert.True(t, isValidFeature("temp"))
@@ -20,6 +29,167 @@ func testIsValidFeature(t *testing.T) {
 	assert.True(t, isValidFeature("cloud_cover"))
 	assert.True(t, isValidFeature("cloud_base"))
 	assert.True(t, isValidFeature("cloud_ceiling"))
+	assert.True(t, isValidFeature("precipitationIntensity"))
 	assert.True(t, isValidFeature("cloud_satellite"))
+	assert.False(t, isValidFeature("sunriseTime"))
 	assert.False(t, isValidFeature("foo"))
 }
+
+func TestObservationLocalTimes(t *testing.T) {
+	o := new(ClimaCellObservation)
+	o.Sunrise.Value = time.Date(2020, 7, 4, 11, 35, 0, 0, time.UTC)
+	o.Sunset.Value = time.Date(2020, 7, 5, 1, 36, 0, 0, time.UTC)
+	o.ObservationTime.Value = time.Date(2020, 7, 4, 17, 0, 0, 0, time.UTC)
+	o.localizeTimes(&geocoding.Coordinates{Latitude: 30.2711286, Longitude: -97.7436995})
+
+	s := o.String()
+	assert.Contains(t, s, "| Sunrise | 6:35 AM CDT | Sunset | 8:36 PM CDT |")
+	assert.Contains(t, s, "| Observed | Sat Jul 4 12:00 PM CDT |")
+}
+
+// austin geocodes every location to Austin, TX.
+type austin struct{}
+
+func (austin) Geocode(string) error { return nil }
+func (austin) Latlong() *geocoding.Coordinates {
+	return &geocoding.Coordinates{Latitude: 30.2711286, Longitude: -97.7436995}
+}
+func (austin) ParsedLocation() string { return "Austin, TX" }
+
+// testClimaCell returns a client of a fake Tomorrow.io API served by
+// handler.
+func testClimaCell(t *testing.T, handler http.HandlerFunc) *ClimaCell {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &ClimaCell{
+		ApiKey:   "key",
+		ApiURL:   server.URL,
+		Geocoder: geocoding.NewCache(austin{}, time.Hour, 1),
+	}
+}
+
+func TestCurrentConditions(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/timelines", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "key", q.Get("apikey"))
+		assert.Equal(t, "30.2711,-97.7437", q.Get("location"))
+		assert.Equal(t, "current,1d", q.Get("timesteps"))
+		assert.Contains(t, strings.Split(q.Get("fields"), ","), "weatherCode")
+		w.Write([]byte(`{"data": {"timelines": [
+			{"timestep": "current", "intervals": [{"startTime": "2020-07-04T17:00:00Z", "values": {
+				"temperature": 91.4, "temperatureApparent": 98.2, "humidity": 52,
+				"precipitationIntensity": 0.05, "precipitationType": 1,
+				"cloudCover": 80, "cloudBase": null, "weatherCode": 4200}}]},
+			{"timestep": "1d", "intervals": [{"startTime": "2020-07-04T11:00:00Z", "values": {
+				"sunriseTime": "2020-07-04T11:35:00Z", "sunsetTime": "2020-07-05T01:36:00Z"}}]}
+		]}}`))
+	})
+	o, err := c.CurrentConditions("austin")
+	require.NoError(t, err)
+	assert.Equal(t, "Austin, TX", o.ParsedLocation)
+	assert.Equal(t, "Light Rain", o.Title())
+	assert.Equal(t, "rain_light", o.WeatherCode.Value)
+	assert.Nil(t, o.CloudBase.Value)
+
+	s := o.String()
+	assert.Contains(t, s, "| Temperature | 91.4 °F | Feels Like | 98.2 °F |")
+	assert.Contains(t, s, "| Precipitation | 0.05 in/hr | Type of Precipitation | rain |")
+	assert.Contains(t, s, "| Sunrise | 6:35 AM CDT | Sunset | 8:36 PM CDT |")
+	assert.Contains(t, s, "| Observed | Sat Jul 4 12:00 PM CDT |")
+}
+
+func TestCurrentConditionsError(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code": 401001, "type": "Invalid Auth", "message": "The method requires authentication but it was not presented or is invalid."}`))
+	})
+	_, err := c.CurrentConditions("austin")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid Auth")
+}
+
+func TestRealtime(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/weather/realtime", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "key", q.Get("apikey"))
+		assert.Equal(t, "30.2711,-97.7437", q.Get("location"))
+		assert.Equal(t, "imperial", q.Get("units"))
+		w.Write([]byte(`{"data": {"time": "2020-07-04T17:00:00Z", "values": {
+			"temperature": 91.4, "humidity": 52, "cloudBase": 1.2, "cloudCeiling": null,
+			"weatherCode": 1101, "epaHealthConcern": 1}},
+			"location": {"lat": 30.2711, "lon": -97.7437}}`))
+	})
+	r, err := c.Realtime(austin{}.Latlong())
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 7, 4, 17, 0, 0, 0, time.UTC), r.Data.Time)
+	v := r.Data.Values
+	assert.Equal(t, 91.4, v.Temperature)
+	assert.Equal(t, 52.0, v.Humidity)
+	require.NotNil(t, v.CloudBase)
+	assert.Equal(t, 1.2, *v.CloudBase)
+	assert.Nil(t, v.CloudCeiling)
+	assert.Equal(t, WeatherCode(1101), v.WeatherCode)
+	require.NotNil(t, v.EPAHealthConcern)
+	assert.Equal(t, 1, *v.EPAHealthConcern)
+}
+
+func TestWeatherCode(t *testing.T) {
+	assert.Equal(t, "Thunderstorm", WeatherCode(8000).String())
+	assert.Equal(t, "tstorm", WeatherCode(8000).Key())
+	assert.Equal(t, "Unknown (42)", WeatherCode(42).String())
+	assert.Equal(t, conditions.LightRain, WeatherCode(4200).Condition())
+	assert.Equal(t, conditions.Unknown, WeatherCode(42).Condition())
+	assert.Equal(t, "freezing_rain", PrecipitationType(3).String())
+}
+
+func TestWeatherLayer(t *testing.T) {
+	c := &ClimaCell{ApiKey: "key"}
+	layer := c.weatherLayer("precipitation")
+	tile := geocoding.NewSlippyMapTile(29, 52, 7)
+	assert.Equal(t, apiURL+"/map/tile/7/29/52/precipitationIntensity/now.png?apikey=key", layer.URL(tile))
+	assert.Equal(t, apiURL+"/map/tile/7/29/52/precipitationIntensity/2020-08-01T12:00:00Z.png?apikey=key",
+		layer.URLAt(tile, time.Date(2020, 8, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, apiURL+"/map/tile/7/29/52/cloudCover/now.png?apikey=key", c.weatherLayer("cloud_satellite").URL(tile),
+		"satellite maps from ClimaCell's v3 API show cloud cover")
+}
+
+func TestMapRequestDefaults(t *testing.T) {
+	req, err := (&MapRequest{Location: "austin"}).withDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, 7, req.Zoom)
+	assert.Equal(t, 512, req.Width)
+	assert.Equal(t, 512, req.Height)
+	require.Len(t, req.Layers, 1)
+	assert.Equal(t, "precipitation", req.Layers[0].Feature)
+	assert.Equal(t, .7, req.Layers[0].Opacity)
+
+	req, err = (&MapRequest{
+		Zoom:   9,
+		Width:  800,
+		Height: 600,
+		Layers: []*MapLayer{{Feature: "temp", Opacity: .4, Blend: mosaic.Multiply}},
+	}).withDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, 9, req.Zoom)
+	assert.Equal(t, 800, req.Width)
+	assert.Equal(t, &MapLayer{Feature: "temp", Opacity: .4, Blend: mosaic.Multiply}, req.Layers[0])
+}
+
+func TestMapRequestInvalid(t *testing.T) {
+	_, err := (&MapRequest{Layers: featureLayers([]string{"temp", "rainbows", "unicorns"})}).withDefaults()
+	require.Error(t, err)
+	assert.Equal(t, "invalid map features: 'rainbows', 'unicorns'", err.Error())
+
+	_, err = (&MapRequest{Zoom: 30}).withDefaults()
+	assert.Error(t, err)
+	_, err = (&MapRequest{Width: -1}).withDefaults()
+	assert.Error(t, err)
+	_, err = (&MapRequest{Layers: []*MapLayer{{Feature: "temp", Opacity: 2}}}).withDefaults()
+	assert.Error(t, err)
+
+	_, err = (&ClimaCell{}).BuildMap("austin", "rainbows")
+	assert.Error(t, err, "invalid features are reported before any requests are made")
+}
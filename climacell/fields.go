@@ -0,0 +1,264 @@
+package climacell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Field is a Tomorrow.io data field, like "temperature".
+type Field string
+
+const (
+	Temperature              Field = "temperature"
+	TemperatureApparent      Field = "temperatureApparent"
+	DewPoint                 Field = "dewPoint"
+	Humidity                 Field = "humidity"
+	WindSpeed                Field = "windSpeed"
+	WindDirection            Field = "windDirection"
+	WindGust                 Field = "windGust"
+	PressureSurfaceLevel     Field = "pressureSurfaceLevel"
+	PressureSeaLevel         Field = "pressureSeaLevel"
+	PrecipitationIntensity   Field = "precipitationIntensity"
+	PrecipitationProbability Field = "precipitationProbability"
+	PrecipitationTypeField   Field = "precipitationType"
+	RainIntensity            Field = "rainIntensity"
+	FreezingRainIntensity    Field = "freezingRainIntensity"
+	SnowIntensity            Field = "snowIntensity"
+	SleetIntensity           Field = "sleetIntensity"
+	RainAccumulation         Field = "rainAccumulation"
+	SnowAccumulation         Field = "snowAccumulation"
+	SleetAccumulation        Field = "sleetAccumulation"
+	IceAccumulation          Field = "iceAccumulation"
+	Visibility               Field = "visibility"
+	CloudCover               Field = "cloudCover"
+	CloudBase                Field = "cloudBase"
+	CloudCeiling             Field = "cloudCeiling"
+	UVIndex                  Field = "uvIndex"
+	UVHealthConcern          Field = "uvHealthConcern"
+	SolarGHI                 Field = "solarGHI"
+	ThunderstormProbability  Field = "thunderstormProbability"
+	WeatherCodeField         Field = "weatherCode"
+
+	// Air quality fields
+	ParticulateMatter25 Field = "particulateMatter25"
+	ParticulateMatter10 Field = "particulateMatter10"
+	PollutantO3         Field = "pollutantO3"
+	PollutantNO2        Field = "pollutantNO2"
+	PollutantCO         Field = "pollutantCO"
+	PollutantSO2        Field = "pollutantSO2"
+	EPAIndex            Field = "epaIndex"
+	EPAPrimaryPollutant Field = "epaPrimaryPollutant"
+	EPAHealthConcern    Field = "epaHealthConcern"
+
+	// Pollen fields
+	TreeIndex  Field = "treeIndex"
+	GrassIndex Field = "grassIndex"
+	WeedIndex  Field = "weedIndex"
+
+	// Daily fields
+	SunriseTime        Field = "sunriseTime"
+	SunsetTime         Field = "sunsetTime"
+	MoonPhase          Field = "moonPhase"
+	WeatherCodeFullDay Field = "weatherCodeFullDay"
+	WeatherCodeDay     Field = "weatherCodeDay"
+	WeatherCodeNight   Field = "weatherCodeNight"
+)
+
+// AirQualityFields are the pollutant concentrations and the US EPA's air
+// quality index. Plans without air quality data reject them.
+var AirQualityFields = []Field{
+	ParticulateMatter25,
+	ParticulateMatter10,
+	PollutantO3,
+	PollutantNO2,
+	PollutantCO,
+	PollutantSO2,
+	EPAIndex,
+	EPAHealthConcern,
+}
+
+// PollenFields are the tree, grass and weed pollen indices. Plans without
+// pollen data reject them.
+var PollenFields = []Field{TreeIndex, GrassIndex, WeedIndex}
+
+type fieldInfo struct {
+	// daily fields only make sense for the 1d timestep.
+	daily bool
+	// aggregate fields are measurements that can be summarized with a
+	// Min, Max or Avg suffix, unlike codes, categories and times.
+	aggregate bool
+	// mapLayer fields can be drawn as map tiles.
+	mapLayer bool
+}
+
+var fields = map[Field]fieldInfo{
+	Temperature:              {aggregate: true, mapLayer: true},
+	TemperatureApparent:      {aggregate: true, mapLayer: true},
+	DewPoint:                 {aggregate: true, mapLayer: true},
+	Humidity:                 {aggregate: true, mapLayer: true},
+	WindSpeed:                {aggregate: true, mapLayer: true},
+	WindDirection:            {aggregate: true, mapLayer: true},
+	WindGust:                 {aggregate: true, mapLayer: true},
+	PressureSurfaceLevel:     {aggregate: true, mapLayer: true},
+	PressureSeaLevel:         {aggregate: true, mapLayer: true},
+	PrecipitationIntensity:   {aggregate: true, mapLayer: true},
+	PrecipitationProbability: {aggregate: true},
+	PrecipitationTypeField:   {},
+	RainIntensity:            {aggregate: true},
+	FreezingRainIntensity:    {aggregate: true},
+	SnowIntensity:            {aggregate: true},
+	SleetIntensity:           {aggregate: true},
+	RainAccumulation:         {aggregate: true},
+	SnowAccumulation:         {aggregate: true},
+	SleetAccumulation:        {aggregate: true},
+	IceAccumulation:          {aggregate: true},
+	Visibility:               {aggregate: true, mapLayer: true},
+	CloudCover:               {aggregate: true, mapLayer: true},
+	CloudBase:                {aggregate: true, mapLayer: true},
+	CloudCeiling:             {aggregate: true, mapLayer: true},
+	UVIndex:                  {aggregate: true},
+	UVHealthConcern:          {},
+	SolarGHI:                 {aggregate: true},
+	ThunderstormProbability:  {aggregate: true},
+	WeatherCodeField:         {},
+	ParticulateMatter25:      {aggregate: true},
+	ParticulateMatter10:      {aggregate: true},
+	PollutantO3:              {aggregate: true},
+	PollutantNO2:             {aggregate: true},
+	PollutantCO:              {aggregate: true},
+	PollutantSO2:             {aggregate: true},
+	EPAIndex:                 {aggregate: true},
+	EPAPrimaryPollutant:      {},
+	EPAHealthConcern:         {},
+	TreeIndex:                {aggregate: true},
+	GrassIndex:               {aggregate: true},
+	WeedIndex:                {aggregate: true},
+	SunriseTime:              {daily: true},
+	SunsetTime:               {daily: true},
+	MoonPhase:                {daily: true},
+	WeatherCodeFullDay:       {daily: true},
+	WeatherCodeDay:           {daily: true},
+	WeatherCodeNight:         {daily: true},
+}
+
+// aggregates are the suffixes that summarize a field over an hourly or
+// daily timestep.
+var aggregates = []string{"Min", "Max", "Avg"}
+
+// Min, Max and Avg summarize f over each hourly or daily interval.
+func (f Field) Min() Field { return f + "Min" }
+func (f Field) Max() Field { return f + "Max" }
+func (f Field) Avg() Field { return f + "Avg" }
+
+// base returns f without its aggregate suffix, if any.
+func (f Field) base() (Field, bool) {
+	for _, suffix := range aggregates {
+		if strings.HasSuffix(string(f), suffix) {
+			return Field(strings.TrimSuffix(string(f), suffix)), true
+		}
+	}
+	return f, false
+}
+
+func (f Field) info() (fieldInfo, bool) {
+	if info, ok := fields[f]; ok {
+		return info, true
+	}
+	base, aggregate := f.base()
+	info, ok := fields[base]
+	return info, ok && aggregate && info.aggregate
+}
+
+// unsupported describes why f can't be requested with timestep, or is empty
+// if it can.
+func (info fieldInfo) unsupported(f Field, timestep string) string {
+	if info.daily && timestep != "1d" {
+		return fmt.Sprintf("'%s' needs the 1d timestep, not %s", f, timestep)
+	}
+	if _, aggregate := f.base(); aggregate && timestep != "1h" && timestep != "1d" {
+		return fmt.Sprintf("'%s' needs the 1h or 1d timestep, not %s", f, timestep)
+	}
+	return ""
+}
+
+// Valid reports whether f is a documented field.
+func (f Field) Valid() bool {
+	_, ok := f.info()
+	return ok
+}
+
+// ValidateFields reports unknown fields and fields that none of timesteps
+// can return, like sunriseTime with only the current timestep.
+func ValidateFields(timesteps []string, fields ...Field) error {
+	problems := []string{}
+	for _, f := range fields {
+		info, ok := f.info()
+		if !ok {
+			problem := fmt.Sprintf("'%s' is not a field", f)
+			if base, aggregate := f.base(); aggregate {
+				if _, known := base.info(); known {
+					problem = fmt.Sprintf("'%s' can't be aggregated", base)
+				}
+			}
+			problems = append(problems, problem)
+			continue
+		}
+		// fields go to every timestep, so one that supports f is enough
+		problem := ""
+		for _, timestep := range timesteps {
+			if problem = info.unsupported(f, timestep); problem == "" {
+				break
+			}
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("unsupported fields: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// mapFeatures are the fields behind the feature names maps take, which
+// date from ClimaCell's v3 API.
+var mapFeatures = map[string]Field{
+	"precipitation":  PrecipitationIntensity,
+	"temp":           Temperature,
+	"wind_speed":     WindSpeed,
+	"wind_direction": WindDirection,
+	"wind_gust":      WindGust,
+	"visibility":     Visibility,
+	"baro_pressure":  PressureSurfaceLevel,
+	"dewpoint":       DewPoint,
+	"humidity":       Humidity,
+	"cloud_cover":    CloudCover,
+	"cloud_base":     CloudBase,
+	"cloud_ceiling":  CloudCeiling,
+	// Tomorrow.io has no satellite imagery; cloud cover is the closest.
+	"cloud_satellite": CloudCover,
+}
+
+// mapField returns the field drawn for a map feature, which is either one
+// of the v3 names in mapFeatures or a field that can be drawn as a map.
+func mapField(feature string) (Field, bool) {
+	feature = strings.TrimSpace(feature)
+	if f, ok := mapFeatures[strings.ToLower(feature)]; ok {
+		return f, true
+	}
+	info, ok := fields[Field(feature)]
+	return Field(feature), ok && info.mapLayer
+}
+
+// MapFeatures returns the names of the features maps can show, sorted.
+func MapFeatures() []string {
+	names := []string{}
+	for name := range mapFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
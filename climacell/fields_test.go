@@ -0,0 +1,46 @@
+package climacell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFields(t *testing.T) {
+	assert.NoError(t, ValidateFields([]string{"current", "1d"}, Temperature, SunriseTime))
+	assert.NoError(t, ValidateFields([]string{"1d"}, Temperature.Max(), WindSpeed.Avg()))
+	assert.True(t, Field("temperatureMin").Valid())
+	assert.False(t, Field("Min").Valid())
+	assert.False(t, Field("rainbowsMax").Valid())
+	for _, f := range []Field{SunriseTime.Max(), WeatherCodeField.Avg(), PrecipitationTypeField.Min(), EPAHealthConcern.Max()} {
+		assert.False(t, f.Valid(), "%s can't be aggregated", f)
+	}
+
+	err := ValidateFields([]string{"current", "1m"}, Temperature, SunriseTime, Temperature.Max(), "rainbows", WeatherCodeField.Max())
+	require.Error(t, err)
+	assert.Equal(t, "unsupported fields: 'sunriseTime' needs the 1d timestep, not 1m; "+
+		"'temperatureMax' needs the 1h or 1d timestep, not 1m; 'rainbows' is not a field; "+
+		"'weatherCode' can't be aggregated", err.Error())
+}
+
+func TestBuildURL(t *testing.T) {
+	c := &ClimaCell{ApiKey: "k&y"}
+	q := &QueryParams{
+		flags:  map[string]string{"location": "30.2711,-97.7437", "timesteps": "current", "units": "imperial"},
+		fields: []Field{Temperature, WeatherCodeField},
+	}
+	assert.Equal(t, apiURL+"/timelines?apikey=k%26y&fields=temperature%2CweatherCode"+
+		"&location=30.2711%2C-97.7437&timesteps=current&units=imperial", c.buildURL("/timelines", q))
+}
+
+func TestMapFeatures(t *testing.T) {
+	for _, feature := range MapFeatures() {
+		field, ok := mapField(feature)
+		require.True(t, ok, feature)
+		assert.True(t, fields[field].mapLayer, feature)
+	}
+	field, ok := mapField("baro_pressure ")
+	assert.True(t, ok)
+	assert.Equal(t, PressureSurfaceLevel, field)
+}
@@ -0,0 +1,219 @@
+package climacell
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultForecastHours = 24
+	maxForecastHours     = 120
+	defaultForecastDays  = 7
+	maxForecastDays      = 15
+)
+
+var hourlyFields = []Field{
+	Temperature,
+	TemperatureApparent,
+	PrecipitationProbability,
+	PrecipitationIntensity,
+	RainAccumulation,
+	SnowAccumulation,
+	WindSpeed,
+	WeatherCodeField,
+}
+
+var dailyFields = []Field{
+	Temperature.Min(),
+	Temperature.Max(),
+	PrecipitationProbability.Max(),
+	RainAccumulation,
+	SnowAccumulation,
+	WindSpeed.Max(),
+	WeatherCodeField,
+	SunriseTime,
+	SunsetTime,
+}
+
+// ForecastHour is the forecast for the hour starting at Time, in °F,
+// inches and mph.
+type ForecastHour struct {
+	Time                     time.Time
+	Conditions               WeatherCode
+	Temperature              float64
+	FeelsLike                float64
+	PrecipitationProbability float64
+	PrecipitationIntensity   float64
+	RainAccumulation         float64
+	SnowAccumulation         float64
+	WindSpeed                float64
+}
+
+// ForecastDay is the forecast for the day of Date, in °F, inches and mph.
+type ForecastDay struct {
+	Date                     time.Time
+	Conditions               WeatherCode
+	Low                      float64
+	High                     float64
+	PrecipitationProbability float64
+	RainAccumulation         float64
+	SnowAccumulation         float64
+	MaxWindSpeed             float64
+	Sunrise                  time.Time
+	Sunset                   time.Time
+}
+
+// HourlyForecast is the forecast for the next hours at a location, in its
+// local time.
+type HourlyForecast struct {
+	ParsedLocation string
+	Hours          []*ForecastHour
+}
+
+// DailyForecast is the forecast for the next days at a location, in its
+// local time.
+type DailyForecast struct {
+	ParsedLocation string
+	Days           []*ForecastDay
+}
+
+// forecast returns the timeline at timestep for count intervals from now at
+// location, and the location's time zone.
+func (c *ClimaCell) forecast(location, timestep string, fields []Field, count int, step time.Duration) (string, *Timeline, *time.Location, error) {
+	place, err := c.geocode(location)
+	if err != nil {
+		return "", nil, nil, errors.Wrapf(err, "failed to find geocoding information for '%s'", location)
+	}
+	coords := place.Coordinates
+	start := time.Now()
+	timelines, err := c.Timelines(&coords, fields, []string{timestep}, time.Time{}, start.Add(time.Duration(count-1)*step))
+	if err != nil {
+		return "", nil, nil, errors.Wrapf(err, "failed to get %s forecast from Tomorrow.io", timestep)
+	}
+	t := timeline(timelines, timestep)
+	if t == nil {
+		return "", nil, nil, errors.Errorf("Tomorrow.io returned no %s forecast", timestep)
+	}
+	if len(t.Intervals) > count {
+		t.Intervals = t.Intervals[:count]
+	}
+	loc, err := coords.TimeZone()
+	if err != nil {
+		loc = time.UTC
+	}
+	return place.ParsedLocation, t, loc, nil
+}
+
+// HourlyForecast returns the forecast for the next hours at location, up
+// to 120. Zero hours means 24.
+func (c *ClimaCell) HourlyForecast(location string, hours int) (*HourlyForecast, error) {
+	if hours == 0 {
+		hours = defaultForecastHours
+	}
+	if hours < 1 || hours > maxForecastHours {
+		return nil, errors.Errorf("%d hours is out of range; expected 1 to %d", hours, maxForecastHours)
+	}
+	parsedLocation, t, loc, err := c.forecast(location, "1h", hourlyFields, hours, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	f := &HourlyForecast{ParsedLocation: parsedLocation, Hours: []*ForecastHour{}}
+	for _, i := range t.Intervals {
+		v := i.Values
+		f.Hours = append(f.Hours, &ForecastHour{
+			Time:                     i.StartTime.In(loc),
+			Conditions:               v.WeatherCode,
+			Temperature:              v.Temperature,
+			FeelsLike:                v.TemperatureApparent,
+			PrecipitationProbability: v.PrecipitationProbability,
+			PrecipitationIntensity:   v.PrecipitationIntensity,
+			RainAccumulation:         v.RainAccumulation,
+			SnowAccumulation:         v.SnowAccumulation,
+			WindSpeed:                v.WindSpeed,
+		})
+	}
+	return f, nil
+}
+
+// DailyForecast returns the forecast for the next days at location, up to
+// 15. Zero days means 7.
+func (c *ClimaCell) DailyForecast(location string, days int) (*DailyForecast, error) {
+	if days == 0 {
+		days = defaultForecastDays
+	}
+	if days < 1 || days > maxForecastDays {
+		return nil, errors.Errorf("%d days is out of range; expected 1 to %d", days, maxForecastDays)
+	}
+	parsedLocation, t, loc, err := c.forecast(location, "1d", dailyFields, days, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	f := &DailyForecast{ParsedLocation: parsedLocation, Days: []*ForecastDay{}}
+	for _, i := range t.Intervals {
+		v := i.Values
+		f.Days = append(f.Days, &ForecastDay{
+			Date:                     i.StartTime.In(loc),
+			Conditions:               v.WeatherCode,
+			Low:                      v.TemperatureMin,
+			High:                     v.TemperatureMax,
+			PrecipitationProbability: v.PrecipitationProbabilityMax,
+			RainAccumulation:         v.RainAccumulation,
+			SnowAccumulation:         v.SnowAccumulation,
+			MaxWindSpeed:             v.WindSpeedMax,
+			Sunrise:                  v.SunriseTime.In(loc),
+			Sunset:                   v.SunsetTime.In(loc),
+		})
+	}
+	return f, nil
+}
+
+var hourlyTemplate = template.Must(template.New("HourlyForecast").Parse(
+	`| Hourly Forecast | {{.ParsedLocation}} | | | | |
+| Time | Conditions | Temperature | Feels Like | Precipitation | Wind |
+| :--- | :--- | ---: | ---: | ---: | ---: |
+{{range .Hours}}| {{.Time.Format "Mon 3 PM"}} | {{.Conditions}} | {{printf "%.0f" .Temperature}} °F | {{printf "%.0f" .FeelsLike}} °F | {{printf "%.0f" .PrecipitationProbability}}%{{if gt .PrecipitationIntensity 0.0}}, {{printf "%.2f" .PrecipitationIntensity}} in/hr{{end}} | {{printf "%.0f" .WindSpeed}} mph |
+{{end}}`))
+
+var dailyTemplate = template.Must(template.New("DailyForecast").Parse(
+	`| Daily Forecast | {{.ParsedLocation}} | | | | |
+| Day | Conditions | Low | High | Precipitation | Wind |
+| :--- | :--- | ---: | ---: | ---: | ---: |
+{{range .Days}}| {{.Date.Format "Mon Jan 2"}} | {{.Conditions}} | {{printf "%.0f" .Low}} °F | {{printf "%.0f" .High}} °F | {{printf "%.0f" .PrecipitationProbability}}%{{if gt .RainAccumulation 0.0}}, {{printf "%.2f" .RainAccumulation}} in rain{{end}}{{if gt .SnowAccumulation 0.0}}, {{printf "%.1f" .SnowAccumulation}} in snow{{end}} | {{printf "%.0f" .MaxWindSpeed}} mph |
+{{end}}`))
+
+func execute(t *template.Template, data interface{}) (string, error) {
+	buffer := new(bytes.Buffer)
+	if err := t.Execute(buffer, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render %s", t.Name())
+	}
+	return buffer.String(), nil
+}
+
+// Markdown renders the forecast as a markdown table.
+func (f *HourlyForecast) Markdown() (string, error) {
+	return execute(hourlyTemplate, f)
+}
+
+// Markdown renders the forecast as a markdown table.
+func (f *DailyForecast) Markdown() (string, error) {
+	return execute(dailyTemplate, f)
+}
+
+func (c *ClimaCell) MarkdownHourlyForecast(location string, hours int) (string, error) {
+	f, err := c.HourlyForecast(location, hours)
+	if err != nil {
+		return "", err
+	}
+	return f.Markdown()
+}
+
+func (c *ClimaCell) MarkdownDailyForecast(location string, days int) (string, error) {
+	f, err := c.DailyForecast(location, days)
+	if err != nil {
+		return "", err
+	}
+	return f.Markdown()
+}
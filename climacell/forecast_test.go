@@ -0,0 +1,57 @@
+package climacell
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHourlyForecast(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1h", r.URL.Query().Get("timesteps"))
+		assert.NotEmpty(t, r.URL.Query().Get("endTime"))
+		w.Write([]byte(`{"data": {"timelines": [{"timestep": "1h", "intervals": [
+			{"startTime": "2020-07-04T17:00:00Z", "values": {"temperature": 91.4, "temperatureApparent": 98.2,
+				"precipitationProbability": 40, "precipitationIntensity": 0.12, "windSpeed": 8.1, "weatherCode": 4001}},
+			{"startTime": "2020-07-04T18:00:00Z", "values": {"temperature": 93, "temperatureApparent": 99,
+				"windSpeed": 7, "weatherCode": 1100}},
+			{"startTime": "2020-07-04T19:00:00Z", "values": {"temperature": 94}}
+		]}]}}`))
+	})
+	f, err := c.HourlyForecast("austin", 2)
+	require.NoError(t, err)
+	require.Len(t, f.Hours, 2, "extra intervals are dropped")
+	assert.Equal(t, "Rain", f.Hours[0].Conditions.String())
+	assert.Equal(t, 12, f.Hours[0].Time.Hour(), "times are local")
+
+	s, err := f.Markdown()
+	require.NoError(t, err)
+	assert.Contains(t, s, "| Hourly Forecast | Austin, TX |")
+	assert.Contains(t, s, "| Sat 12 PM | Rain | 91 °F | 98 °F | 40%, 0.12 in/hr | 8 mph |")
+	assert.Contains(t, s, "| Sat 1 PM | Mostly Clear | 93 °F | 99 °F | 0% | 7 mph |")
+
+	_, err = c.HourlyForecast("austin", 500)
+	assert.Error(t, err)
+}
+
+func TestDailyForecast(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1d", r.URL.Query().Get("timesteps"))
+		w.Write([]byte(`{"data": {"timelines": [{"timestep": "1d", "intervals": [
+			{"startTime": "2020-12-24T12:00:00Z", "values": {"temperatureMin": 28.4, "temperatureMax": 35.6,
+				"precipitationProbabilityMax": 80, "rainAccumulation": 0.1, "snowAccumulation": 2.25,
+				"windSpeedMax": 15, "weatherCode": 5100}}
+		]}]}}`))
+	})
+	f, err := c.DailyForecast("austin", 0)
+	require.NoError(t, err)
+	require.Len(t, f.Days, 1)
+	s, err := f.Markdown()
+	require.NoError(t, err)
+	assert.Contains(t, s, "| Thu Dec 24 | Light Snow | 28 °F | 36 °F | 80%, 0.10 in rain, 2.2 in snow | 15 mph |")
+
+	_, err = c.DailyForecast("austin", 16)
+	assert.Error(t, err)
+}
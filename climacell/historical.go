@@ -0,0 +1,86 @@
+package climacell
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type historicalRequest struct {
+	Location  string   `json:"location"`
+	Fields    []Field  `json:"fields"`
+	Timesteps []string `json:"timesteps"`
+	StartTime string   `json:"startTime"`
+	EndTime   string   `json:"endTime"`
+	Units     string   `json:"units"`
+}
+
+// Historical returns observations at the place found for query every
+// timestep, which is "1h" or "1d", from start to end. Tomorrow.io serves its
+// own and station data together through one endpoint, so unlike ClimaCell's
+// v3 API there's no choice between them.
+func (c *ClimaCell) Historical(query, timestep string, start, end time.Time) ([]*Observation, error) {
+	if timestep != "1h" && timestep != "1d" {
+		return nil, errors.Errorf("unsupported historical timestep %s; expected 1h or 1d", timestep)
+	}
+	if !start.Before(end) {
+		return nil, errors.Errorf("historical start %s isn't before end %s",
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	fields := []Field{}
+	for _, f := range append(append([]Field{}, currentFields...), c.ExtraFields...) {
+		if info, _ := f.info(); !info.daily || timestep == "1d" {
+			fields = append(fields, f)
+		}
+	}
+	if err := ValidateFields([]string{timestep}, fields...); err != nil {
+		return nil, err
+	}
+
+	place, err := c.geocode(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find geocoding information for '%s'", query)
+	}
+	coords := place.Coordinates
+	r := new(timelinesResponse)
+	err = c.post("/historical", &historicalRequest{
+		Location:  location(&coords),
+		Fields:    fields,
+		Timesteps: []string{timestep},
+		StartTime: start.UTC().Format(time.RFC3339),
+		EndTime:   end.UTC().Format(time.RFC3339),
+		Units:     "imperial",
+	}, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get historical weather from Tomorrow.io")
+	}
+	t := timeline(r.Data.Timelines, timestep)
+	if t == nil {
+		return nil, errors.Errorf("Tomorrow.io returned no %s history", timestep)
+	}
+
+	observations := []*Observation{}
+	for i := range t.Intervals {
+		cco := newObservation(&coords, &t.Intervals[i])
+		cco.Sunrise.Value = t.Intervals[i].Values.SunriseTime
+		cco.Sunset.Value = t.Intervals[i].Values.SunsetTime
+		cco.localizeTimes(&coords)
+		observations = append(observations, &Observation{ClimaCellObservation: cco, ParsedLocation: place.ParsedLocation})
+	}
+	return observations, nil
+}
+
+func (c *ClimaCell) MarkdownHistorical(location, timestep string, start, end time.Time) (string, error) {
+	observations, err := c.Historical(location, timestep, start, end)
+	if err != nil {
+		return "", err
+	}
+	tables := []string{}
+	for _, o := range observations {
+		tables = append(tables, fmt.Sprintf("| %s | %s | Location  | %s |\n| :--- | ---: | :--- | ---: |\n%s",
+			o.ObservationTime.Value.Format("Mon Jan 2 3:04 PM"), o.Title(), o.ParsedLocation, o.String()))
+	}
+	return strings.Join(tables, "\n"), nil
+}
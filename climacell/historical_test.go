@@ -0,0 +1,64 @@
+package climacell
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorical(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/historical", r.URL.Path)
+		assert.Equal(t, "key", r.URL.Query().Get("apikey"))
+		req := new(historicalRequest)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		assert.Equal(t, "30.2711,-97.7437", req.Location)
+		assert.Equal(t, []string{"1h"}, req.Timesteps)
+		assert.Equal(t, "2020-07-04T12:00:00Z", req.StartTime)
+		assert.Contains(t, req.Fields, EPAIndex)
+		assert.NotContains(t, req.Fields, SunriseTime, "daily fields are left out of hourly history")
+		w.Write([]byte(`{"data": {"timelines": [{"timestep": "1h", "intervals": [
+			{"startTime": "2020-07-04T12:00:00Z", "values": {"temperature": 80.1, "weatherCode": 1000,
+				"epaIndex": 42, "epaHealthConcern": 0, "pollutantO3": 31, "particulateMatter25": 8.5,
+				"particulateMatter10": 12, "pollutantNO2": 9.5, "pollutantCO": 0.3, "pollutantSO2": 1.2}},
+			{"startTime": "2020-07-04T13:00:00Z", "values": {"temperature": 82.3, "weatherCode": 1100}}
+		]}]}}`))
+	})
+	c.ExtraFields = AirQualityFields
+	start := time.Date(2020, 7, 4, 12, 0, 0, 0, time.UTC)
+	observations, err := c.Historical("austin", "1h", start, start.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, observations, 2)
+	assert.Equal(t, "Clear, Sunny", observations[0].Title())
+	s := observations[0].String()
+	assert.Contains(t, s, "| Observed | Sat Jul 4 7:00 AM CDT |")
+	assert.Contains(t, s, "| Air Quality | 42 AQI, Good | Ozone | 31 ppb |")
+	assert.Contains(t, s, "| PM2.5 | 8.5 µg/m³ | PM10 | 12 µg/m³ |")
+	assert.Contains(t, s, "| NO2 | 9.5 ppb | CO | 0.3 ppm |")
+	assert.Contains(t, s, "| SO2 | 1.2 ppb | | |")
+	r := observations[0].Render()
+	assert.Contains(t, r.Fields, render.Field{Label: "Ozone", Value: "31 ppb"})
+	assert.Contains(t, r.Fields, render.Field{Label: "NO2", Value: "9.5 ppb"})
+	assert.Contains(t, r.Fields, render.Field{Label: "CO", Value: "0.3 ppm"})
+	assert.Contains(t, r.Fields, render.Field{Label: "SO2", Value: "1.2 ppb"})
+	assert.NotContains(t, observations[1].String(), "Air Quality", "unreported air quality is left out")
+
+	_, err = c.Historical("austin", "5m", start, start.Add(time.Hour))
+	assert.Error(t, err)
+	_, err = c.Historical("austin", "1h", start, start)
+	assert.Error(t, err)
+}
+
+func TestObservationPollen(t *testing.T) {
+	low, none, high := 2, 0, 4
+	o := newObservation(austin{}.Latlong(), &Interval{Values: Values{TreeIndex: &low, GrassIndex: &none, WeedIndex: &high}})
+	s := o.String()
+	assert.Contains(t, s, "| Tree Pollen | Low | Grass Pollen | None |")
+	assert.Contains(t, s, "| Weed Pollen | High | | |")
+}
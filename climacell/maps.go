@@ -0,0 +1,234 @@
+package climacell
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/mosaic"
+	"github.com/pkg/errors"
+)
+
+// Animated maps cover from animationPast before now to animationFuture
+// after, a frame every animationStep.
+const (
+	animationPast   = time.Hour
+	animationFuture = time.Hour
+	animationStep   = 10 * time.Minute
+	animationDelay  = 500 * time.Millisecond
+)
+
+// Map defaults, used for anything a MapRequest leaves unset.
+const (
+	defaultMapZoom    = 7
+	defaultMapSize    = 512
+	defaultMapOpacity = .7
+	maxMapZoom        = 19
+	maxMapSize        = 4096
+)
+
+// MapLayer is one of ClimaCell's map layers and how to draw it over the
+// layers beneath.
+type MapLayer struct {
+	Feature string
+	// Opacity is between 0 and 1, defaulting to 0.7 when zero.
+	Opacity float64
+	Blend   mosaic.BlendMode
+}
+
+// MapRequest describes a map of Layers around Location. Zero values take
+// the defaults: zoom 7, 512x512 pixels, PNG, and a precipitation layer.
+type MapRequest struct {
+	Location string
+	Layers   []*MapLayer
+	Zoom     int
+	Width    int
+	Height   int
+	Format   mosaic.ImageFormat
+}
+
+// withDefaults returns a copy of r with unset fields filled in, or an error
+// if r asks for something impossible.
+func (r *MapRequest) withDefaults() (*MapRequest, error) {
+	req := *r
+	if req.Zoom == 0 {
+		req.Zoom = defaultMapZoom
+	}
+	if req.Width == 0 {
+		req.Width = defaultMapSize
+	}
+	if req.Height == 0 {
+		req.Height = defaultMapSize
+	}
+	if req.Zoom < 1 || req.Zoom > maxMapZoom {
+		return nil, errors.Errorf("zoom %d is out of range; expected 1 to %d", req.Zoom, maxMapZoom)
+	}
+	if req.Width < 1 || req.Width > maxMapSize || req.Height < 1 || req.Height > maxMapSize {
+		return nil, errors.Errorf("map size %dx%d is out of range; expected at most %dx%d",
+			req.Width, req.Height, maxMapSize, maxMapSize)
+	}
+
+	req.Layers = []*MapLayer{}
+	invalid := []string{}
+	for _, layer := range r.Layers {
+		if !isValidFeature(layer.Feature) {
+			invalid = append(invalid, fmt.Sprintf("'%s'", layer.Feature))
+			continue
+		}
+		if layer.Opacity < 0 || layer.Opacity > 1 {
+			return nil, errors.Errorf("opacity %g for %s is out of range; expected 0 to 1", layer.Opacity, layer.Feature)
+		}
+		l := *layer
+		if l.Opacity == 0 {
+			l.Opacity = defaultMapOpacity
+		}
+		req.Layers = append(req.Layers, &l)
+	}
+	if len(invalid) > 0 {
+		return nil, errors.Errorf("invalid map features: %s", strings.Join(invalid, ", "))
+	}
+	if len(req.Layers) == 0 {
+		req.Layers = []*MapLayer{{Feature: "precipitation", Opacity: defaultMapOpacity}}
+	}
+	return &req, nil
+}
+
+func (r *MapRequest) viewport(place *geo.Place) *mosaic.Viewport {
+	return &mosaic.Viewport{Center: place.Coordinates, Zoom: r.Zoom, Width: r.Width, Height: r.Height}
+}
+
+// featureLayers turns feature names into layers with the default opacity.
+func featureLayers(features []string) []*MapLayer {
+	layers := []*MapLayer{}
+	for _, feature := range features {
+		layers = append(layers, &MapLayer{Feature: feature})
+	}
+	return layers
+}
+
+// BuildMap renders a default map of features around location as a PNG.
+func (c *ClimaCell) BuildMap(location string, features ...string) ([]byte, error) {
+	return c.Map(&MapRequest{Location: location, Layers: featureLayers(features)})
+}
+
+// Map renders the map described by r.
+func (c *ClimaCell) Map(r *MapRequest) ([]byte, error) {
+	req, err := r.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	place, err := c.geocode(req.Location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find geocoding information for '%s'", req.Location)
+	}
+
+	layers := []*mosaic.Layer{{Source: c.basemap()}}
+	for _, layer := range req.Layers {
+		layers = append(layers, &mosaic.Layer{
+			Source:  c.weatherLayer(layer.Feature),
+			Opacity: layer.Opacity,
+			Blend:   layer.Blend,
+		})
+	}
+	viewport := req.viewport(place)
+	img, err := mosaic.Render(viewport, layers...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render map")
+	}
+	mosaic.Annotate(img, viewport, mapAnnotations(place, layers))
+
+	buf := new(bytes.Buffer)
+	err = mosaic.Encode(buf, img, req.Format)
+	return buf.Bytes(), err
+}
+
+// BuildAnimatedMap renders features around location as a loop from an hour
+// of past observations through the next hour's nowcast, encoded in format.
+// Each frame is labeled with its local time.
+func (c *ClimaCell) BuildAnimatedMap(location string, format mosaic.AnimationFormat, features ...string) ([]byte, error) {
+	now := time.Now()
+	return c.AnimatedMap(&MapRequest{Location: location, Layers: featureLayers(features)},
+		format, now.Add(-animationPast), now.Add(animationFuture))
+}
+
+// AnimatedMap renders the map described by r from start to end, encoded in
+// format. r's Format is ignored.
+func (c *ClimaCell) AnimatedMap(r *MapRequest, format mosaic.AnimationFormat, start, end time.Time) ([]byte, error) {
+	req, err := r.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	place, err := c.geocode(req.Location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find geocoding information for '%s'", req.Location)
+	}
+
+	basemap := c.basemap()
+	attributed := []*mosaic.Layer{{Source: basemap}}
+	layers := []*mosaic.TimedLayer{}
+	for _, layer := range req.Layers {
+		source := c.weatherLayer(layer.Feature)
+		layers = append(layers, &mosaic.TimedLayer{Source: source, Opacity: layer.Opacity, Blend: layer.Blend})
+		attributed = append(attributed, &mosaic.Layer{Source: source})
+	}
+	tz, err := place.Coordinates.TimeZone()
+	if err != nil {
+		tz = time.UTC
+	}
+	animation := &mosaic.Animation{
+		Viewport:    req.viewport(place),
+		Basemap:     basemap,
+		Layers:      layers,
+		Times:       mosaic.Timesteps(start, end, animationStep),
+		Annotations: mapAnnotations(place, attributed),
+		Location:    tz,
+	}
+	frames, err := animation.Frames()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render animated map")
+	}
+
+	buf := new(bytes.Buffer)
+	err = mosaic.EncodeAnimation(buf, format, frames, animationDelay)
+	return buf.Bytes(), err
+}
+
+// mapAnnotations marks place on the map. Tomorrow.io doesn't publish the
+// color scales of its tiles, so unlike OpenWeatherMap's maps these have no
+// legends.
+func mapAnnotations(place *geo.Place, layers []*mosaic.Layer) *mosaic.Annotations {
+	return &mosaic.Annotations{
+		Marker:      &place.Coordinates,
+		Label:       place.ParsedLocation,
+		ScaleBar:    true,
+		Attribution: mosaic.Attribution(layers...),
+	}
+}
+
+// basemap returns OpenStreetMap, fetched through the tile cache if there
+// is one.
+func (c *ClimaCell) basemap() mosaic.TileSource {
+	basemap := *mosaic.OpenStreetMap
+	if cache := c.tileCache(); cache != nil {
+		basemap.Client = cache.Client()
+	}
+	return &basemap
+}
+
+// weatherLayer returns a tile source for one of ClimaCell's map layers,
+// either now or at a given time.
+func (c *ClimaCell) weatherLayer(feature string) *mosaic.XYZSource {
+	field, _ := mapField(feature)
+	return &mosaic.XYZSource{
+		Source: mosaic.Source{
+			Name:        fmt.Sprintf("Tomorrow.io %s", feature),
+			Attribution: "Weather data © Tomorrow.io",
+			APIKey:      c.ApiKey,
+		},
+		URLTemplate: fmt.Sprintf("%s/map/tile/{z}/{x}/{y}/%s/{time}.png?apikey={apikey}", c.apiURL(), url.PathEscape(string(field))),
+		CurrentTime: "now",
+	}
+}
@@ -0,0 +1,209 @@
+package climacell
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	nowcastHorizon = 6 * time.Hour
+
+	// precipitationThreshold is the intensity in in/hr below which we
+	// consider it dry.
+	precipitationThreshold = 0.01
+
+	// sparklineMax is the intensity in in/hr drawn as a full block.
+	sparklineMax = 0.4
+)
+
+var nowcastFields = []Field{
+	PrecipitationIntensity,
+	PrecipitationProbability,
+	PrecipitationTypeField,
+}
+
+var nowcastTimesteps = map[time.Duration]string{
+	time.Minute:      "1m",
+	5 * time.Minute:  "5m",
+	15 * time.Minute: "15m",
+}
+
+// NowcastPoint is the precipitation forecast at Time.
+type NowcastPoint struct {
+	Time        time.Time
+	Intensity   float64 // in/hr
+	Probability float64 // %
+	Type        PrecipitationType
+}
+
+// Nowcast is the precipitation forecast for the next six hours at a
+// location, in its local time.
+type Nowcast struct {
+	ParsedLocation string
+	Timestep       time.Duration
+	Points         []*NowcastPoint
+}
+
+// Nowcast returns the precipitation forecast for the next six hours at
+// location every timestep, which is a minute, 5 minutes or 15 minutes. Zero
+// means a minute.
+func (c *ClimaCell) Nowcast(location string, timestep time.Duration) (*Nowcast, error) {
+	if timestep == 0 {
+		timestep = time.Minute
+	}
+	step, ok := nowcastTimesteps[timestep]
+	if !ok {
+		return nil, errors.Errorf("unsupported nowcast timestep %s; expected 1m, 5m or 15m", timestep)
+	}
+	parsedLocation, t, loc, err := c.forecast(location, step, nowcastFields, int(nowcastHorizon/timestep)+1, timestep)
+	if err != nil {
+		return nil, err
+	}
+	n := &Nowcast{ParsedLocation: parsedLocation, Timestep: timestep, Points: []*NowcastPoint{}}
+	for _, i := range t.Intervals {
+		n.Points = append(n.Points, &NowcastPoint{
+			Time:        i.StartTime.In(loc),
+			Intensity:   i.Values.PrecipitationIntensity,
+			Probability: i.Values.PrecipitationProbability,
+			Type:        i.Values.PrecipitationType,
+		})
+	}
+	return n, nil
+}
+
+// PrecipitationEvent is a spell of precipitation in a nowcast. End is zero
+// when it lasts beyond the nowcast.
+type PrecipitationEvent struct {
+	Start time.Time
+	End   time.Time
+	Type  PrecipitationType
+	// Peak is the highest intensity in in/hr.
+	Peak float64
+}
+
+// Intensity describes the peak intensity of the event as "light",
+// "moderate" or "heavy".
+func (e *PrecipitationEvent) Intensity() string {
+	switch {
+	case e.Peak < 0.1:
+		return "light"
+	case e.Peak < 0.3:
+		return "moderate"
+	default:
+		return "heavy"
+	}
+}
+
+// Precipitation returns the spells of precipitation in the nowcast.
+func (n *Nowcast) Precipitation() []*PrecipitationEvent {
+	events := []*PrecipitationEvent{}
+	var current *PrecipitationEvent
+	for _, p := range n.Points {
+		if p.Intensity < precipitationThreshold {
+			if current != nil {
+				current.End = p.Time
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			current = &PrecipitationEvent{Start: p.Time, Type: p.Type}
+			events = append(events, current)
+		}
+		if p.Intensity > current.Peak {
+			current.Peak = p.Intensity
+			if p.Type != 0 {
+				current.Type = p.Type
+			}
+		}
+	}
+	return events
+}
+
+func precipitationName(t PrecipitationType) string {
+	if t == 0 {
+		return "Precipitation"
+	}
+	name := strings.Replace(t.String(), "_", " ", -1)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Summary says when precipitation starts and stops in the nowcast and how
+// heavy it gets.
+func (n *Nowcast) Summary() string {
+	events := n.Precipitation()
+	if len(events) == 0 {
+		return "No precipitation expected in the next 6 hours."
+	}
+	e := events[0]
+	start := fmt.Sprintf("starting at %s", e.Start.Format("3:04 PM"))
+	if len(n.Points) > 0 && !e.Start.After(n.Points[0].Time) {
+		start = "now"
+	}
+	end := "continuing for at least 6 hours"
+	if !e.End.IsZero() {
+		end = fmt.Sprintf("stopping at %s", e.End.Format("3:04 PM"))
+	}
+	s := fmt.Sprintf("%s %s, %s, peaking %s (%.2f in/hr).",
+		precipitationName(e.Type), start, end, e.Intensity(), e.Peak)
+	switch more := len(events) - 1; {
+	case more == 1:
+		s += " 1 more spell after that."
+	case more > 1:
+		s += fmt.Sprintf(" %d more spells after that.", more)
+	}
+	return s
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws the intensity of the nowcast in width characters, each
+// the heaviest precipitation of its part of the nowcast.
+func (n *Nowcast) Sparkline(width int) string {
+	if width < 1 || len(n.Points) == 0 {
+		return ""
+	}
+	if width > len(n.Points) {
+		width = len(n.Points)
+	}
+	line := make([]rune, width)
+	for i := range line {
+		peak := 0.
+		for _, p := range n.Points[i*len(n.Points)/width : (i+1)*len(n.Points)/width] {
+			if p.Intensity > peak {
+				peak = p.Intensity
+			}
+		}
+		level := 0
+		if peak >= precipitationThreshold {
+			// anything wet is at least one step above dry
+			level = 1 + int(peak/sparklineMax*float64(len(sparks)-2))
+			if level >= len(sparks) {
+				level = len(sparks) - 1
+			}
+		}
+		line[i] = sparks[level]
+	}
+	return string(line)
+}
+
+// String renders the nowcast compactly for chat, as its summary and a
+// sparkline of the next six hours.
+func (n *Nowcast) String() string {
+	if len(n.Points) == 0 {
+		return n.Summary()
+	}
+	return fmt.Sprintf("%s\n`%s %s %s`", n.Summary(),
+		n.Points[0].Time.Format("3:04 PM"), n.Sparkline(36), n.Points[len(n.Points)-1].Time.Format("3:04 PM"))
+}
+
+func (c *ClimaCell) MarkdownNowcast(location string) (string, error) {
+	n, err := c.Nowcast(location, 0)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("**%s**: %s", n.ParsedLocation, n), nil
+}
@@ -0,0 +1,70 @@
+package climacell
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNowcast returns a nowcast from 3 PM UTC with an intensity every
+// 5 minutes.
+func testNowcast(intensities ...float64) *Nowcast {
+	start := time.Date(2020, 7, 4, 15, 0, 0, 0, time.UTC)
+	n := &Nowcast{Timestep: 5 * time.Minute}
+	for i, intensity := range intensities {
+		n.Points = append(n.Points, &NowcastPoint{
+			Time:      start.Add(time.Duration(i) * n.Timestep),
+			Intensity: intensity,
+			Type:      1,
+		})
+	}
+	return n
+}
+
+func TestNowcastPrecipitation(t *testing.T) {
+	n := testNowcast(0, 0, .05, .2, .35, .1, 0, 0, .02)
+	events := n.Precipitation()
+	require.Len(t, events, 2)
+	assert.Equal(t, "3:10PM", events[0].Start.Format("3:04PM"))
+	assert.Equal(t, "3:30PM", events[0].End.Format("3:04PM"))
+	assert.Equal(t, .35, events[0].Peak)
+	assert.Equal(t, "heavy", events[0].Intensity())
+	assert.True(t, events[1].End.IsZero(), "rain at the end of the nowcast continues")
+
+	assert.Equal(t, "Rain starting at 3:10 PM, stopping at 3:30 PM, peaking heavy (0.35 in/hr). 1 more spell after that.",
+		n.Summary())
+	assert.Contains(t, testNowcast(.05, 0, .05, 0, .05).Summary(), " 2 more spells after that.")
+	assert.Equal(t, "Rain now, continuing for at least 6 hours, peaking light (0.05 in/hr).",
+		testNowcast(.05, .05).Summary())
+	assert.Equal(t, "No precipitation expected in the next 6 hours.", testNowcast(0, 0, 0).Summary())
+}
+
+func TestNowcastSparkline(t *testing.T) {
+	n := testNowcast(0, 0, .05, .2, .35, .1, 0, 0, .5)
+	assert.Equal(t, "▁▁▂▅▇▃▁▁█", n.Sparkline(20))
+	assert.Equal(t, "▂▇█", n.Sparkline(3))
+	assert.Equal(t, "", (&Nowcast{}).Sparkline(10))
+}
+
+func TestNowcast(t *testing.T) {
+	c := testClimaCell(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "5m", r.URL.Query().Get("timesteps"))
+		w.Write([]byte(`{"data": {"timelines": [{"timestep": "5m", "intervals": [
+			{"startTime": "2020-07-04T17:00:00Z", "values": {"precipitationIntensity": 0}},
+			{"startTime": "2020-07-04T17:05:00Z", "values": {"precipitationIntensity": 0.12,
+				"precipitationProbability": 75, "precipitationType": 2}}
+		]}]}}`))
+	})
+	n, err := c.Nowcast("austin", 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, n.Points, 2)
+	assert.Equal(t, "Snow starting at 12:05 PM, continuing for at least 6 hours, peaking moderate (0.12 in/hr).",
+		n.Summary())
+	assert.Contains(t, n.String(), "`12:00 PM ▁▃ 12:05 PM`")
+
+	_, err = c.Nowcast("austin", time.Hour)
+	assert.Error(t, err)
+}
@@ -1,27 +1,42 @@
 package climacell
 
 import (
-	"fmt"
+	"net/url"
 	"strings"
 )
 
 type QueryParams struct {
 	flags  map[string]string
-	fields []string
+	fields []Field
 }
 
-func (q QueryParams) String() string {
-	flags := ""
-	fields := ""
+// Values returns the parameters ready to encode.
+func (q *QueryParams) Values() url.Values {
+	values := url.Values{}
+	for key, value := range q.flags {
+		values.Set(key, value)
+	}
 	if len(q.fields) > 0 {
-		fields = fmt.Sprintf("fields=%s", strings.Join(q.fields, `%2C`))
+		fields := make([]string, len(q.fields))
+		for i, f := range q.fields {
+			fields[i] = string(f)
+		}
+		values.Set("fields", strings.Join(fields, ","))
 	}
-	for key, value := range q.flags {
-		flags = fmt.Sprintf("%s&%s=%s", flags, key, value)
+	return values
+}
+
+func (c *ClimaCell) apiURL() string {
+	if c.ApiURL != "" {
+		return c.ApiURL
 	}
-	return fmt.Sprintf("%s&%s", flags, fields)
+	return apiURL
 }
 
+// buildURL returns the URL of endpoint with queryParams, which are encoded
+// in a stable order.
 func (c *ClimaCell) buildURL(endpoint string, queryParams *QueryParams) string {
-	return fmt.Sprintf("%s%s?apikey=%s%s", apiURL, endpoint, c.ApiKey, queryParams)
+	values := queryParams.Values()
+	values.Set("apikey", c.ApiKey)
+	return c.apiURL() + endpoint + "?" + values.Encode()
 }
@@ -0,0 +1,114 @@
+package climacell
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/render"
+)
+
+// Render returns the observation for any of the render package's
+// renderers.
+func (o *Observation) Render() *render.Observation {
+	c := o.ClimaCellObservation
+	r := &render.Observation{
+		Location: o.ParsedLocation,
+		Time:     render.At(c.ObservationTime.Value),
+		Conditions: conditions.Report{
+			Condition: c.Code.Condition(),
+			Night:     conditions.IsNight(c.ObservationTime.Value, c.Sunrise.Value, c.Sunset.Value),
+		},
+	}
+	if c.Code == 0 {
+		r.Description = c.Title()
+	}
+	add := func(label, format string, args ...interface{}) {
+		r.Fields = append(r.Fields, render.Field{Label: label, Value: fmt.Sprintf(format, args...)})
+	}
+	add("Temperature", "%.1f °%s", c.Temp.Value, c.Temp.Units)
+	add("Feels Like", "%.1f °%s", c.FeelsLike.Value, c.FeelsLike.Units)
+	if c.Precipitation.Value != 0 {
+		add("Precipitation", "%.2f %s", c.Precipitation.Value, c.Precipitation.Units)
+		add("Type of Precipitation", "%s", c.PrecipitationType.Value)
+	}
+	add("Wind Gust", "%.1f %s", c.WindGust.Value, c.WindGust.Units)
+	add("Barometric Pressure", "%.2f %s", c.BaroPressure.Value, c.BaroPressure.Units)
+	add("Humidity", "%.0f%s", c.Humidity.Value, c.Humidity.Units)
+	add("Cloud Cover", "%.0f%s", c.CloudCover.Value, c.CloudCover.Units)
+	if c.EPAHealthConcern.Value != "" {
+		add("Air Quality", "%.0f AQI, %s", c.EPAAQI.Value, c.EPAHealthConcern.Value)
+	}
+	pollutants := []struct {
+		label, units string
+		value        float64
+	}{
+		{"Ozone", c.O3.Units, c.O3.Value},
+		{"PM2.5", c.PM25.Units, c.PM25.Value},
+		{"PM10", c.PM10.Units, c.PM10.Value},
+		{"NO2", c.NO2.Units, c.NO2.Value},
+		{"CO", c.CO.Units, c.CO.Value},
+		{"SO2", c.SO2.Units, c.SO2.Value},
+	}
+	for _, p := range pollutants {
+		if p.units != "" {
+			add(p.label, "%g %s", p.value, p.units)
+		}
+	}
+	if c.PollenTree.Value != "" {
+		add("Pollen", "Tree %s, Grass %s, Weed %s", c.PollenTree.Value, c.PollenGrass.Value, c.PollenWeed.Value)
+	}
+	if !c.Sunrise.Value.IsZero() {
+		add("Sunrise", "%s", c.Sunrise.Value.Format("3:04 PM MST"))
+		add("Sunset", "%s", c.Sunset.Value.Format("3:04 PM MST"))
+	}
+	return r
+}
+
+// Render returns the forecast for any of the render package's renderers.
+func (f *HourlyForecast) Render() *render.Forecast {
+	r := &render.Forecast{Title: "Hourly Forecast", Location: f.ParsedLocation}
+	for _, h := range f.Hours {
+		r.Periods = append(r.Periods, &render.Period{
+			Name: h.Time.Format("Mon 3 PM"),
+			Time: render.At(h.Time),
+			Conditions: conditions.Report{
+				Condition: h.Conditions.Condition(),
+				Night:     conditions.IsNight(h.Time, time.Time{}, time.Time{}),
+			},
+			Fields: []render.Field{
+				{Label: "Temperature", Value: fmt.Sprintf("%.0f °F", h.Temperature)},
+				{Label: "Feels Like", Value: fmt.Sprintf("%.0f °F", h.FeelsLike)},
+				{Label: "Precipitation", Value: fmt.Sprintf("%.0f%%", h.PrecipitationProbability)},
+				{Label: "Wind", Value: fmt.Sprintf("%.0f mph", h.WindSpeed)},
+			},
+		})
+	}
+	return r
+}
+
+// Render returns the forecast for any of the render package's renderers.
+func (f *DailyForecast) Render() *render.Forecast {
+	r := &render.Forecast{Title: "Daily Forecast", Location: f.ParsedLocation}
+	for _, d := range f.Days {
+		precipitation := fmt.Sprintf("%.0f%%", d.PrecipitationProbability)
+		if d.RainAccumulation > 0 {
+			precipitation += fmt.Sprintf(", %.2f in rain", d.RainAccumulation)
+		}
+		if d.SnowAccumulation > 0 {
+			precipitation += fmt.Sprintf(", %.1f in snow", d.SnowAccumulation)
+		}
+		r.Periods = append(r.Periods, &render.Period{
+			Name:       d.Date.Format("Mon Jan 2"),
+			Time:       render.At(d.Date),
+			Conditions: conditions.Report{Condition: d.Conditions.Condition()},
+			Fields: []render.Field{
+				{Label: "Low", Value: fmt.Sprintf("%.0f °F", d.Low)},
+				{Label: "High", Value: fmt.Sprintf("%.0f °F", d.High)},
+				{Label: "Precipitation", Value: precipitation},
+				{Label: "Wind", Value: fmt.Sprintf("%.0f mph", d.MaxWindSpeed)},
+			},
+		})
+	}
+	return r
+}
@@ -0,0 +1,40 @@
+package climacell
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservationRender(t *testing.T) {
+	cdt := time.FixedZone("CDT", -5*60*60)
+	o := &Observation{ParsedLocation: "Austin, TX", ClimaCellObservation: newObservation(austin{}.Latlong(), &Interval{
+		StartTime: time.Date(2020, 7, 4, 22, 0, 0, 0, cdt),
+		Values:    Values{Temperature: 84.2, WeatherCode: 1000},
+	})}
+	o.Sunrise.Value = time.Date(2020, 7, 4, 6, 35, 0, 0, cdt)
+	o.Sunset.Value = time.Date(2020, 7, 4, 20, 36, 0, 0, cdt)
+
+	r := o.Render()
+	assert.Equal(t, conditions.Report{Condition: conditions.Clear, Night: true}, r.Conditions)
+	assert.Equal(t, render.Field{Label: "Temperature", Value: "84.2 °F"}, r.Fields[0])
+
+	s, err := render.String(render.Markdown{}, &render.Weather{Observation: r})
+	require.NoError(t, err)
+	assert.Contains(t, s, "| Current Conditions | 🌙 Clear | Location | Austin, TX |")
+	assert.Contains(t, s, "| Sunrise | 6:35 AM CDT | Sunset | 8:36 PM CDT |")
+}
+
+func TestDailyForecastRender(t *testing.T) {
+	f := &DailyForecast{ParsedLocation: "Austin, TX", Days: []*ForecastDay{{
+		Date: time.Date(2020, 12, 24, 6, 0, 0, 0, time.UTC), Conditions: 5100, Low: 28.4, High: 35.6,
+		PrecipitationProbability: 80, SnowAccumulation: 2,
+	}}}
+	s, err := render.String(render.Text{}, &render.Weather{Forecast: f.Render()})
+	require.NoError(t, err)
+	assert.Equal(t, "Daily Forecast for Austin, TX:\nThu Dec 24: Light Snow; Low 28 °F; High 36 °F; Precipitation 80%, 2.0 in snow; Wind 0 mph\n", s)
+}
@@ -0,0 +1,196 @@
+package climacell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+)
+
+// Values are the data fields of a Tomorrow.io interval, in imperial units.
+// Fields that weren't requested are zero.
+type Values struct {
+	Temperature              float64           `json:"temperature"`
+	TemperatureApparent      float64           `json:"temperatureApparent"`
+	DewPoint                 float64           `json:"dewPoint"`
+	Humidity                 float64           `json:"humidity"`
+	WindSpeed                float64           `json:"windSpeed"`
+	WindDirection            float64           `json:"windDirection"`
+	WindGust                 float64           `json:"windGust"`
+	PressureSurfaceLevel     float64           `json:"pressureSurfaceLevel"`
+	PressureSeaLevel         float64           `json:"pressureSeaLevel"`
+	PrecipitationIntensity   float64           `json:"precipitationIntensity"`
+	PrecipitationProbability float64           `json:"precipitationProbability"`
+	PrecipitationType        PrecipitationType `json:"precipitationType"`
+	RainIntensity            float64           `json:"rainIntensity"`
+	SnowIntensity            float64           `json:"snowIntensity"`
+	SleetIntensity           float64           `json:"sleetIntensity"`
+	FreezingRainIntensity    float64           `json:"freezingRainIntensity"`
+	Visibility               float64           `json:"visibility"`
+	CloudCover               float64           `json:"cloudCover"`
+	// CloudBase and CloudCeiling are nil when there are no clouds.
+	CloudBase    *float64    `json:"cloudBase"`
+	CloudCeiling *float64    `json:"cloudCeiling"`
+	UVIndex      float64     `json:"uvIndex"`
+	WeatherCode  WeatherCode `json:"weatherCode"`
+	SunriseTime  time.Time   `json:"sunriseTime"`
+	SunsetTime   time.Time   `json:"sunsetTime"`
+
+	// Daily timesteps summarize the day with the minimum and maximum of
+	// fields requested with a "Min" or "Max" suffix.
+	TemperatureMin              float64 `json:"temperatureMin"`
+	TemperatureMax              float64 `json:"temperatureMax"`
+	WindSpeedMax                float64 `json:"windSpeedMax"`
+	PrecipitationProbabilityMax float64 `json:"precipitationProbabilityMax"`
+	RainAccumulation            float64 `json:"rainAccumulation"`
+	SnowAccumulation            float64 `json:"snowAccumulation"`
+
+	// Air quality and pollen fields are nil when they weren't requested.
+	ParticulateMatter25 *float64 `json:"particulateMatter25"`
+	ParticulateMatter10 *float64 `json:"particulateMatter10"`
+	PollutantO3         *float64 `json:"pollutantO3"`
+	PollutantNO2        *float64 `json:"pollutantNO2"`
+	PollutantCO         *float64 `json:"pollutantCO"`
+	PollutantSO2        *float64 `json:"pollutantSO2"`
+	EPAIndex            *float64 `json:"epaIndex"`
+	EPAHealthConcern    *int     `json:"epaHealthConcern"`
+	TreeIndex           *int     `json:"treeIndex"`
+	GrassIndex          *int     `json:"grassIndex"`
+	WeedIndex           *int     `json:"weedIndex"`
+}
+
+// Interval is a Values at StartTime.
+type Interval struct {
+	StartTime time.Time `json:"startTime"`
+	Values    Values    `json:"values"`
+}
+
+// Timeline is a series of intervals at one timestep, like "1h".
+type Timeline struct {
+	Timestep  string     `json:"timestep"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   time.Time  `json:"endTime"`
+	Intervals []Interval `json:"intervals"`
+}
+
+type timelinesResponse struct {
+	Data struct {
+		Timelines []*Timeline `json:"timelines"`
+	} `json:"data"`
+}
+
+// Realtime is the response of the realtime weather endpoint.
+type Realtime struct {
+	Data struct {
+		Time   time.Time `json:"time"`
+		Values Values    `json:"values"`
+	} `json:"data"`
+}
+
+// apiError is the body Tomorrow.io sends with errors.
+type apiError struct {
+	Code    int    `json:"code"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func location(coords *geo.Coordinates) string {
+	return fmt.Sprintf("%0.4f,%0.4f", coords.Latitude, coords.Longitude)
+}
+
+// get requests endpoint and decodes the response into v.
+func (c *ClimaCell) get(endpoint string, params *QueryParams, v interface{}) error {
+	return c.do(http.MethodGet, endpoint, params, nil, v)
+}
+
+// post sends request to endpoint as JSON and decodes the response into v.
+func (c *ClimaCell) post(endpoint string, request, v interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+	return c.do(http.MethodPost, endpoint, &QueryParams{}, bytes.NewReader(body), v)
+}
+
+func (c *ClimaCell) do(method, endpoint string, params *QueryParams, request io.Reader, v interface{}) error {
+	req, err := http.NewRequest(method, c.buildURL(endpoint, params), request)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build request for %s", endpoint)
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s from Tomorrow.io", endpoint)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read body from response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := new(apiError)
+		if json.Unmarshal(body, e) == nil && e.Message != "" {
+			return errors.Errorf("Tomorrow.io %s returned %d: %s: %s", endpoint, resp.StatusCode, e.Type, e.Message)
+		}
+		return errors.Errorf("Tomorrow.io %s returned %d", endpoint, resp.StatusCode)
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal JSON from body")
+}
+
+// Timelines returns fields at coords for each of timesteps, such as
+// "current", "1h" or "1d", from start to end. A zero start means now and a
+// zero end the API's default.
+func (c *ClimaCell) Timelines(coords *geo.Coordinates, fields []Field, timesteps []string, start, end time.Time) ([]*Timeline, error) {
+	if err := ValidateFields(timesteps, fields...); err != nil {
+		return nil, err
+	}
+	flags := map[string]string{
+		"location":  location(coords),
+		"timesteps": strings.Join(timesteps, ","),
+		"units":     "imperial",
+		"startTime": "now",
+	}
+	if !start.IsZero() {
+		flags["startTime"] = start.UTC().Format(time.RFC3339)
+	}
+	if !end.IsZero() {
+		flags["endTime"] = end.UTC().Format(time.RFC3339)
+	}
+	r := new(timelinesResponse)
+	if err := c.get("/timelines", &QueryParams{flags: flags, fields: fields}, r); err != nil {
+		return nil, err
+	}
+	return r.Data.Timelines, nil
+}
+
+// Realtime returns the weather at coords right now.
+func (c *ClimaCell) Realtime(coords *geo.Coordinates) (*Realtime, error) {
+	r := new(Realtime)
+	err := c.get("/weather/realtime", &QueryParams{flags: map[string]string{
+		"location": location(coords),
+		"units":    "imperial",
+	}}, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// timeline returns the timeline at timestep.
+func timeline(timelines []*Timeline, timestep string) *Timeline {
+	for _, t := range timelines {
+		if t.Timestep == timestep {
+			return t
+		}
+	}
+	return nil
+}
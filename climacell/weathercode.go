@@ -0,0 +1,79 @@
+package climacell
+
+import (
+	"fmt"
+
+	"github.com/gigawhitlocks/weather/conditions"
+)
+
+// WeatherCode is Tomorrow.io's integer code for the weather conditions,
+// e.g. 4200 for light rain.
+type WeatherCode int
+
+type weatherCodeInfo struct {
+	// key is the v3 API's name for the code, which existing users of
+	// Observation know.
+	key         string
+	description string
+	condition   conditions.Condition
+}
+
+var weatherCodes = map[WeatherCode]weatherCodeInfo{
+	1000: {"clear", "Clear, Sunny", conditions.Clear},
+	1100: {"mostly_clear", "Mostly Clear", conditions.MostlyClear},
+	1101: {"partly_cloudy", "Partly Cloudy", conditions.PartlyCloudy},
+	1102: {"mostly_cloudy", "Mostly Cloudy", conditions.MostlyCloudy},
+	1001: {"cloudy", "Cloudy", conditions.Cloudy},
+	2000: {"fog", "Fog", conditions.Fog},
+	2100: {"fog_light", "Light Fog", conditions.LightFog},
+	4000: {"drizzle", "Drizzle", conditions.Drizzle},
+	4001: {"rain", "Rain", conditions.Rain},
+	4200: {"rain_light", "Light Rain", conditions.LightRain},
+	4201: {"rain_heavy", "Heavy Rain", conditions.HeavyRain},
+	5000: {"snow", "Snow", conditions.Snow},
+	5001: {"flurries", "Flurries", conditions.Flurries},
+	5100: {"snow_light", "Light Snow", conditions.LightSnow},
+	5101: {"snow_heavy", "Heavy Snow", conditions.HeavySnow},
+	6000: {"freezing_drizzle", "Freezing Drizzle", conditions.FreezingDrizzle},
+	6001: {"freezing_rain", "Freezing Rain", conditions.FreezingRain},
+	6200: {"freezing_rain_light", "Light Freezing Rain", conditions.LightFreezingRain},
+	6201: {"freezing_rain_heavy", "Heavy Freezing Rain", conditions.HeavyFreezingRain},
+	7000: {"ice_pellets", "Ice Pellets", conditions.Sleet},
+	7101: {"ice_pellets_heavy", "Heavy Ice Pellets", conditions.HeavySleet},
+	7102: {"ice_pellets_light", "Light Ice Pellets", conditions.LightSleet},
+	8000: {"tstorm", "Thunderstorm", conditions.Thunderstorm},
+}
+
+// String describes the weather, e.g. "Light Rain".
+func (w WeatherCode) String() string {
+	if info, ok := weatherCodes[w]; ok {
+		return info.description
+	}
+	if w == 0 {
+		return "Unknown"
+	}
+	return fmt.Sprintf("Unknown (%d)", int(w))
+}
+
+// Key returns the v3 API's name for the code, e.g. "rain_light".
+func (w WeatherCode) Key() string {
+	return weatherCodes[w].key
+}
+
+// Condition returns the provider independent condition for the code.
+func (w WeatherCode) Condition() conditions.Condition {
+	return weatherCodes[w].condition
+}
+
+// PrecipitationType is Tomorrow.io's integer code for the type of
+// precipitation.
+type PrecipitationType int
+
+var precipitationTypes = []string{"none", "rain", "snow", "freezing_rain", "ice_pellets"}
+
+func (p PrecipitationType) String() string {
+	if p < 0 || int(p) >= len(precipitationTypes) {
+		return "unknown"
+	}
+	return precipitationTypes[p]
+}
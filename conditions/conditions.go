@@ -0,0 +1,185 @@
+// Package conditions describes the weather the same way whichever provider
+// reported it, so chat output can use one set of names, emoji and icons.
+package conditions
+
+import "time"
+
+// Condition is the kind of weather, like light rain.
+type Condition int
+
+const (
+	Unknown Condition = iota
+	Clear
+	MostlyClear
+	PartlyCloudy
+	MostlyCloudy
+	Cloudy
+	LightFog
+	Fog
+	Haze
+	Smoke
+	Dust
+	Drizzle
+	LightRain
+	Rain
+	HeavyRain
+	Showers
+	Flurries
+	LightSnow
+	Snow
+	HeavySnow
+	Blizzard
+	RainAndSnow
+	LightSleet
+	Sleet
+	HeavySleet
+	FreezingDrizzle
+	LightFreezingRain
+	FreezingRain
+	HeavyFreezingRain
+	Thunderstorm
+	Windy
+	Tornado
+	TropicalStorm
+	Hurricane
+	Hot
+	Cold
+)
+
+type info struct {
+	description string
+	slug        string
+	emoji       string
+	// nightEmoji is the emoji at night, for conditions that look different
+	// then. Those conditions have day and night icons.
+	nightEmoji string
+}
+
+var conditions = map[Condition]info{
+	Unknown:           {"Unknown", "unknown", "❔", ""},
+	Clear:             {"Clear", "clear", "☀️", "🌙"},
+	MostlyClear:       {"Mostly Clear", "mostly-clear", "🌤️", "🌙"},
+	PartlyCloudy:      {"Partly Cloudy", "partly-cloudy", "⛅", "☁️"},
+	MostlyCloudy:      {"Mostly Cloudy", "mostly-cloudy", "🌥️", "☁️"},
+	Cloudy:            {"Cloudy", "cloudy", "☁️", ""},
+	LightFog:          {"Light Fog", "light-fog", "🌫️", ""},
+	Fog:               {"Fog", "fog", "🌫️", ""},
+	Haze:              {"Haze", "haze", "🌫️", ""},
+	Smoke:             {"Smoke", "smoke", "🌫️", ""},
+	Dust:              {"Dust", "dust", "🌫️", ""},
+	Drizzle:           {"Drizzle", "drizzle", "🌦️", "🌧️"},
+	LightRain:         {"Light Rain", "light-rain", "🌦️", "🌧️"},
+	Rain:              {"Rain", "rain", "🌧️", ""},
+	HeavyRain:         {"Heavy Rain", "heavy-rain", "🌧️", ""},
+	Showers:           {"Showers", "showers", "🌦️", "🌧️"},
+	Flurries:          {"Flurries", "flurries", "🌨️", ""},
+	LightSnow:         {"Light Snow", "light-snow", "🌨️", ""},
+	Snow:              {"Snow", "snow", "🌨️", ""},
+	HeavySnow:         {"Heavy Snow", "heavy-snow", "❄️", ""},
+	Blizzard:          {"Blizzard", "blizzard", "❄️", ""},
+	RainAndSnow:       {"Rain and Snow", "rain-and-snow", "🌨️", ""},
+	LightSleet:        {"Light Sleet", "light-sleet", "🌨️", ""},
+	Sleet:             {"Sleet", "sleet", "🌨️", ""},
+	HeavySleet:        {"Heavy Sleet", "heavy-sleet", "🌨️", ""},
+	FreezingDrizzle:   {"Freezing Drizzle", "freezing-drizzle", "🧊", ""},
+	LightFreezingRain: {"Light Freezing Rain", "light-freezing-rain", "🧊", ""},
+	FreezingRain:      {"Freezing Rain", "freezing-rain", "🧊", ""},
+	HeavyFreezingRain: {"Heavy Freezing Rain", "heavy-freezing-rain", "🧊", ""},
+	Thunderstorm:      {"Thunderstorm", "thunderstorm", "⛈️", ""},
+	Windy:             {"Windy", "windy", "🌬️", ""},
+	Tornado:           {"Tornado", "tornado", "🌪️", ""},
+	TropicalStorm:     {"Tropical Storm", "tropical-storm", "🌀", ""},
+	Hurricane:         {"Hurricane", "hurricane", "🌀", ""},
+	Hot:               {"Hot", "hot", "🌡️", ""},
+	Cold:              {"Cold", "cold", "🥶", ""},
+}
+
+func (c Condition) info() info {
+	if i, ok := conditions[c]; ok {
+		return i
+	}
+	return conditions[Unknown]
+}
+
+// String describes the condition, like "Light Rain".
+func (c Condition) String() string {
+	return c.info().description
+}
+
+// Slug names the condition in lowercase words joined by dashes, like
+// "light-rain".
+func (c Condition) Slug() string {
+	return c.info().slug
+}
+
+// MarshalText encodes the condition as its slug.
+func (c Condition) MarshalText() ([]byte, error) {
+	return []byte(c.Slug()), nil
+}
+
+// UnmarshalText decodes a slug, leaving unrecognized ones Unknown.
+func (c *Condition) UnmarshalText(text []byte) error {
+	*c = Unknown
+	for condition, i := range conditions {
+		if i.slug == string(text) {
+			*c = condition
+		}
+	}
+	return nil
+}
+
+// HasNightVariant reports whether the condition looks different at night.
+func (c Condition) HasNightVariant() bool {
+	return c.info().nightEmoji != ""
+}
+
+// Report is a condition at day or night.
+type Report struct {
+	Condition Condition `json:"condition"`
+	Night     bool      `json:"night"`
+}
+
+func (r Report) String() string {
+	return r.Condition.String()
+}
+
+// Emoji returns an emoji for the report for chat, like "🌙" for a clear
+// night.
+func (r Report) Emoji() string {
+	i := r.Condition.info()
+	if r.Night && i.nightEmoji != "" {
+		return i.nightEmoji
+	}
+	return i.emoji
+}
+
+// IconName returns the name of an icon for the report: its condition's slug
+// with "-day" or "-night" for conditions that look different at night, like
+// "partly-cloudy-night" or "rain".
+func (r Report) IconName() string {
+	if !r.Condition.HasNightVariant() {
+		return r.Condition.Slug()
+	}
+	if r.Night {
+		return r.Condition.Slug() + "-night"
+	}
+	return r.Condition.Slug() + "-day"
+}
+
+// IsNight reports whether the time of day of t is before sunrise or after
+// sunset, which may be from another day. Without a sunrise and sunset it's
+// night from 6 PM to 6 AM in t's time zone.
+func IsNight(t, sunrise, sunset time.Time) bool {
+	if sunrise.IsZero() || sunset.IsZero() {
+		return t.Hour() < 6 || t.Hour() >= 18
+	}
+	now := clock(t, t.Location())
+	return now < clock(sunrise, t.Location()) || now >= clock(sunset, t.Location())
+}
+
+// clock returns the time of day of t in loc as a duration since midnight.
+func clock(t time.Time, loc *time.Location) time.Duration {
+	t = t.In(loc)
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
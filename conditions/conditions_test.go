@@ -0,0 +1,65 @@
+package conditions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	assert.Equal(t, "☀️", Report{Condition: Clear}.Emoji())
+	assert.Equal(t, "🌙", Report{Condition: Clear, Night: true}.Emoji())
+	assert.Equal(t, "clear-night", Report{Condition: Clear, Night: true}.IconName())
+	assert.Equal(t, "partly-cloudy-day", Report{Condition: PartlyCloudy}.IconName())
+	assert.Equal(t, "heavy-snow", Report{Condition: HeavySnow, Night: true}.IconName())
+	assert.Equal(t, "Light Rain", Report{Condition: LightRain}.String())
+	assert.Equal(t, "Unknown", Condition(1000).String())
+}
+
+func TestEveryConditionIsDescribed(t *testing.T) {
+	for c := Unknown; c <= Cold; c++ {
+		_, ok := conditions[c]
+		assert.True(t, ok, "condition %d", int(c))
+	}
+}
+
+func TestFromProviders(t *testing.T) {
+	assert.Equal(t, Report{Condition: Thunderstorm}, FromOpenWeatherMap(211, "11d"))
+	assert.Equal(t, Report{Condition: PartlyCloudy, Night: true}, FromOpenWeatherMap(802, "03n"))
+
+	assert.Equal(t, Report{Condition: Thunderstorm, Night: true},
+		FromNWS("https://api.weather.gov/icons/land/night/tsra,40/sct?size=medium", "Thunderstorms"))
+	assert.Equal(t, Report{Condition: MostlyCloudy},
+		FromNWS("https://api.weather.gov/icons/land/day/bkn?size=medium", ""))
+	assert.Equal(t, Report{Condition: Fog}, FromNWS("", "Fog/Mist"))
+}
+
+func TestFromText(t *testing.T) {
+	assert.Equal(t, Thunderstorm, FromText("Light Rain and Thunderstorms"))
+	assert.Equal(t, PartlyCloudy, FromText("Partly Sunny"))
+	assert.Equal(t, MostlyClear, FromText("Mostly Sunny"))
+	assert.Equal(t, LightSnow, FromText("Light Snow and Fog"))
+	assert.Equal(t, Unknown, FromText(""))
+}
+
+func TestIsNight(t *testing.T) {
+	cdt := time.FixedZone("CDT", -5*60*60)
+	sunrise := time.Date(2020, 7, 4, 6, 35, 0, 0, cdt)
+	sunset := time.Date(2020, 7, 4, 20, 36, 0, 0, cdt)
+	assert.False(t, IsNight(time.Date(2020, 7, 5, 12, 0, 0, 0, cdt), sunrise, sunset))
+	assert.True(t, IsNight(time.Date(2020, 7, 5, 21, 0, 0, 0, cdt), sunrise, sunset))
+	assert.True(t, IsNight(time.Date(2020, 7, 5, 6, 0, 0, 0, cdt), sunrise.UTC(), sunset.UTC()))
+	assert.True(t, IsNight(time.Date(2020, 7, 5, 23, 0, 0, 0, cdt), time.Time{}, time.Time{}))
+}
+
+func TestConditionText(t *testing.T) {
+	text, err := HeavyFreezingRain.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "heavy-freezing-rain", string(text))
+	var c Condition
+	assert.NoError(t, c.UnmarshalText(text))
+	assert.Equal(t, HeavyFreezingRain, c)
+	assert.NoError(t, c.UnmarshalText([]byte("rainbows")))
+	assert.Equal(t, Unknown, c)
+}
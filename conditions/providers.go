@@ -0,0 +1,146 @@
+package conditions
+
+import (
+	"net/url"
+	"strings"
+)
+
+var openWeatherMapIDs = map[int]Condition{
+	300: Drizzle, 301: Drizzle, 302: Drizzle, 310: Drizzle, 311: Drizzle,
+	312: Drizzle, 313: Showers, 314: Showers, 321: Showers,
+	500: LightRain, 501: Rain, 502: HeavyRain, 503: HeavyRain, 504: HeavyRain,
+	511: FreezingRain, 520: Showers, 521: Showers, 522: HeavyRain, 531: Showers,
+	600: LightSnow, 601: Snow, 602: HeavySnow, 611: Sleet, 612: LightSleet,
+	613: Sleet, 615: RainAndSnow, 616: RainAndSnow, 620: Flurries, 621: Snow,
+	622: HeavySnow,
+	701: LightFog, 711: Smoke, 721: Haze, 731: Dust, 741: Fog, 751: Dust,
+	761: Dust, 762: Dust, 771: Windy, 781: Tornado,
+	800: Clear, 801: MostlyClear, 802: PartlyCloudy, 803: MostlyCloudy, 804: Cloudy,
+}
+
+// FromOpenWeatherMap returns the report for an OpenWeatherMap condition ID
+// and icon, which ends in "n" at night.
+func FromOpenWeatherMap(id int, icon string) Report {
+	c, ok := openWeatherMapIDs[id]
+	if !ok && id >= 200 && id < 300 {
+		c = Thunderstorm
+	}
+	return Report{Condition: c, Night: strings.HasSuffix(icon, "n")}
+}
+
+var nwsIcons = map[string]Condition{
+	"skc":             Clear,
+	"few":             MostlyClear,
+	"sct":             PartlyCloudy,
+	"bkn":             MostlyCloudy,
+	"ovc":             Cloudy,
+	"wind_skc":        Windy,
+	"wind_few":        Windy,
+	"wind_sct":        Windy,
+	"wind_bkn":        Windy,
+	"wind_ovc":        Windy,
+	"snow":            Snow,
+	"rain_snow":       RainAndSnow,
+	"rain_sleet":      Sleet,
+	"snow_sleet":      Sleet,
+	"fzra":            FreezingRain,
+	"rain_fzra":       FreezingRain,
+	"snow_fzra":       FreezingRain,
+	"sleet":           Sleet,
+	"rain":            Rain,
+	"rain_showers":    Showers,
+	"rain_showers_hi": Showers,
+	"tsra":            Thunderstorm,
+	"tsra_sct":        Thunderstorm,
+	"tsra_hi":         Thunderstorm,
+	"tornado":         Tornado,
+	"hurricane":       Hurricane,
+	"tropical_storm":  TropicalStorm,
+	"dust":            Dust,
+	"smoke":           Smoke,
+	"haze":            Haze,
+	"hot":             Hot,
+	"cold":            Cold,
+	"blizzard":        Blizzard,
+	"fog":             Fog,
+}
+
+// FromNWS returns the report for a National Weather Service observation or
+// forecast from its icon URL, like
+// https://api.weather.gov/icons/land/night/tsra,40?size=medium, falling
+// back to its text description.
+func FromNWS(icon, description string) Report {
+	r := Report{Condition: FromText(description)}
+	u, err := url.Parse(icon)
+	if err != nil {
+		return r
+	}
+	// the path is /icons/<land|marine>/<day|night>/<code>[,<chance>][/<code>...]
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 4 {
+		return r
+	}
+	r.Night = segments[2] == "night"
+	code := strings.SplitN(segments[3], ",", 2)[0]
+	if c, ok := nwsIcons[code]; ok {
+		r.Condition = c
+	}
+	return r
+}
+
+// keywords are checked in order, so more specific ones come first.
+var keywords = []struct {
+	words     []string
+	condition Condition
+}{
+	{[]string{"thunder", "t-storm", "tstorm"}, Thunderstorm},
+	{[]string{"tornado", "funnel"}, Tornado},
+	{[]string{"hurricane"}, Hurricane},
+	{[]string{"tropical storm"}, TropicalStorm},
+	{[]string{"blizzard"}, Blizzard},
+	{[]string{"freezing drizzle"}, FreezingDrizzle},
+	{[]string{"light freezing rain"}, LightFreezingRain},
+	{[]string{"heavy freezing rain"}, HeavyFreezingRain},
+	{[]string{"freezing rain"}, FreezingRain},
+	{[]string{"light sleet", "light ice pellets"}, LightSleet},
+	{[]string{"heavy sleet", "heavy ice pellets"}, HeavySleet},
+	{[]string{"sleet", "ice pellets"}, Sleet},
+	{[]string{"rain and snow", "snow and rain", "wintry mix"}, RainAndSnow},
+	{[]string{"light snow"}, LightSnow},
+	{[]string{"heavy snow"}, HeavySnow},
+	{[]string{"flurries"}, Flurries},
+	{[]string{"snow"}, Snow},
+	{[]string{"drizzle"}, Drizzle},
+	{[]string{"showers"}, Showers},
+	{[]string{"light rain"}, LightRain},
+	{[]string{"heavy rain", "downpour"}, HeavyRain},
+	{[]string{"rain"}, Rain},
+	{[]string{"light fog"}, LightFog},
+	{[]string{"fog"}, Fog},
+	{[]string{"mist"}, LightFog},
+	{[]string{"haze"}, Haze},
+	{[]string{"smoke"}, Smoke},
+	{[]string{"dust", "sand"}, Dust},
+	{[]string{"wind", "breezy"}, Windy},
+	{[]string{"mostly cloudy"}, MostlyCloudy},
+	{[]string{"partly cloudy", "partly sunny"}, PartlyCloudy},
+	{[]string{"cloudy", "overcast"}, Cloudy},
+	{[]string{"mostly clear", "mostly sunny"}, MostlyClear},
+	{[]string{"clear", "sunny", "fair"}, Clear},
+	{[]string{"hot"}, Hot},
+	{[]string{"cold"}, Cold},
+}
+
+// FromText returns the most severe condition a description like "Light
+// Rain and Fog" mentions.
+func FromText(description string) Condition {
+	description = strings.ToLower(description)
+	for _, k := range keywords {
+		for _, word := range k.words {
+			if strings.Contains(description, word) {
+				return k.condition
+			}
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,275 @@
+package geocoding
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQuotaExhausted is returned by Cache instead of calling a geocoder that
+// has reported it is about to run out of requests.
+var ErrQuotaExhausted = errors.New("geocoding quota exhausted")
+
+// QuotaReporter is implemented by geocoders whose responses report how many
+// requests are left before the service starts refusing them.
+type QuotaReporter interface {
+	Quota() (remaining int, reset time.Time, ok bool)
+}
+
+// Place is a geocoding result as stored by Cache.
+type Place struct {
+	Coordinates    Coordinates `json:"coordinates"`
+	ParsedLocation string      `json:"parsed_location"`
+	Expires        time.Time   `json:"expires"`
+}
+
+// Store persists cached places between processes.
+type Store interface {
+	Load(key string) (*Place, error)
+	Save(key string, place *Place) error
+}
+
+var _ Geocoder = &Cache{}
+
+// Cache wraps a Geocoder and remembers its results for TTL, keeping the Size
+// most recently used in memory and, if Store is set, every result on disk.
+// Once the wrapped geocoder reports Reserve or fewer requests remaining, the
+// cache answers from what it has and refuses everything else until the
+// quota resets.
+type Cache struct {
+	Geocoder Geocoder
+	TTL      time.Duration
+	Size     int
+	Store    Store
+	Reserve  int
+
+	upstream  sync.Mutex
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	order     *list.List
+	current   *Place
+	remaining int
+	reset     time.Time
+	hasQuota  bool
+}
+
+type cacheEntry struct {
+	key   string
+	place *Place
+}
+
+func NewCache(geocoder Geocoder, ttl time.Duration, size int) *Cache {
+	return &Cache{Geocoder: geocoder, TTL: ttl, Size: size}
+}
+
+// Lookup returns the place for location, asking the wrapped geocoder only if
+// no unexpired result is cached. Unlike Geocode it is safe to call from
+// several goroutines.
+func (c *Cache) Lookup(location string) (*Place, error) {
+	key := NormalizeQuery(location)
+	if place := c.cached(key); place != nil {
+		return place, nil
+	}
+
+	// The wrapped geocoder keeps only its last result, so it answers one
+	// query at a time. Cached places are still served while it works.
+	c.upstream.Lock()
+	defer c.upstream.Unlock()
+	if place := c.cached(key); place != nil {
+		return place, nil
+	}
+	if c.Store != nil {
+		place, err := c.Store.Load(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load '%s' from the geocoding store", location)
+		}
+		if place != nil && time.Now().Before(place.Expires) {
+			c.mu.Lock()
+			c.add(key, place)
+			c.mu.Unlock()
+			return place, nil
+		}
+	}
+
+	if remaining, reset, ok := c.Remaining(); ok && remaining <= c.Reserve && time.Now().Before(reset) {
+		return nil, errors.Wrapf(ErrQuotaExhausted, "%d requests remaining until %s", remaining, reset.Format(time.RFC3339))
+	}
+	err := c.Geocoder.Geocode(location)
+	if q, ok := c.Geocoder.(QuotaReporter); ok {
+		// Refusals report the quota too, and are most likely when it's low.
+		c.mu.Lock()
+		c.remaining, c.reset, c.hasQuota = q.Quota()
+		c.mu.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	coords := c.Geocoder.Latlong()
+	if coords == nil {
+		return nil, errors.Errorf("no results found for location '%s'", location)
+	}
+	place := &Place{
+		Coordinates:    *coords,
+		ParsedLocation: c.Geocoder.ParsedLocation(),
+		Expires:        time.Now().Add(c.TTL),
+	}
+	c.mu.Lock()
+	c.add(key, place)
+	c.mu.Unlock()
+	if c.Store != nil {
+		if err := c.Store.Save(key, place); err != nil {
+			return nil, errors.Wrapf(err, "failed to save '%s' to the geocoding store", location)
+		}
+	}
+	return place, nil
+}
+
+// cached returns the unexpired place in memory for key, if there is one.
+func (c *Cache) cached(key string) *Place {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// Remaining reports how many requests the wrapped geocoder said it has left,
+// if it reports a quota at all.
+func (c *Cache) Remaining() (remaining int, reset time.Time, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remaining, c.reset, c.hasQuota
+}
+
+func (c *Cache) Geocode(location string) error {
+	place, err := c.Lookup(location)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.current = place
+	c.mu.Unlock()
+	return nil
+}
+
+// Latlong returns nil before the first successful Geocode.
+func (c *Cache) Latlong() *Coordinates {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil
+	}
+	coords := c.current.Coordinates
+	return &coords
+}
+
+func (c *Cache) ParsedLocation() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return ""
+	}
+	return c.current.ParsedLocation
+}
+
+func (c *Cache) get(key string) *Place {
+	if c.entries == nil {
+		return nil
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := e.Value.(*cacheEntry)
+	if time.Now().After(entry.place.Expires) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return entry.place
+}
+
+func (c *Cache) add(key string, place *Place) {
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.order = list.New()
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).place = place
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, place: place})
+	for c.Size > 0 && c.order.Len() > c.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+var (
+	whitespace  = regexp.MustCompile(`\s+`)
+	commaSpaces = regexp.MustCompile(`\s*,\s*`)
+)
+
+// NormalizeQuery reduces a location query to the key it is cached under, so
+// that "Austin, TX", " austin ,tx" and "AUSTIN,  TX." share one entry.
+func NormalizeQuery(query string) string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	query = whitespace.ReplaceAllString(query, " ")
+	query = commaSpaces.ReplaceAllString(query, ", ")
+	return strings.TrimRight(query, " .,;")
+}
+
+// FileStore keeps one JSON file per cached place in Dir.
+type FileStore struct {
+	Dir string
+}
+
+var _ Store = &FileStore{}
+
+func (f *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load returns nil without error if key has never been saved.
+func (f *FileStore) Load(key string) (*Place, error) {
+	body, err := ioutil.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	place := new(Place)
+	if err = json.Unmarshal(body, place); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal cached place")
+	}
+	return place, nil
+}
+
+func (f *FileStore) Save(key string, place *Place) error {
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+	body, err := json.Marshal(place)
+	if err != nil {
+		return err
+	}
+	tmp := f.path(key) + ".tmp"
+	if err = ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(key))
+}
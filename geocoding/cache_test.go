@@ -0,0 +1,176 @@
+package geocoding
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingGeocoder struct {
+	calls     map[string]int
+	location  string
+	remaining int
+	err       error
+	// started and release, if set, hold each call until the test lets it
+	// finish.
+	started chan string
+	release chan struct{}
+}
+
+func (g *countingGeocoder) Geocode(location string) error {
+	if g.started != nil {
+		g.started <- location
+		<-g.release
+	}
+	if g.calls == nil {
+		g.calls = map[string]int{}
+	}
+	g.calls[location]++
+	g.location = location
+	g.remaining--
+	return g.err
+}
+
+func (g *countingGeocoder) Latlong() *Coordinates {
+	return &Coordinates{Latitude: float64(len(g.location)), Longitude: 1}
+}
+
+func (g *countingGeocoder) ParsedLocation() string {
+	return g.location
+}
+
+func (g *countingGeocoder) Quota() (int, time.Time, bool) {
+	return g.remaining, time.Now().Add(time.Hour), true
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	assert.Equal(t, "austin, tx", NormalizeQuery("Austin, TX"))
+	assert.Equal(t, "austin, tx", NormalizeQuery("  austin ,tx "))
+	assert.Equal(t, "austin, tx", NormalizeQuery("AUSTIN,\tTX."))
+	assert.Equal(t, "new york", NormalizeQuery("new   york"))
+}
+
+func TestCacheHitsAndEviction(t *testing.T) {
+	g := &countingGeocoder{remaining: 100}
+	c := NewCache(g, time.Hour, 2)
+
+	for _, q := range []string{"Austin, TX", "austin,tx", "AUSTIN, TX"} {
+		require.NoError(t, c.Geocode(q))
+		assert.Equal(t, "Austin, TX", c.ParsedLocation())
+	}
+	assert.Equal(t, 1, g.calls["Austin, TX"])
+
+	_, err := c.Lookup("Denver")
+	require.NoError(t, err)
+	_, err = c.Lookup("Boston")
+	require.NoError(t, err)
+	_, err = c.Lookup("Austin, TX")
+	require.NoError(t, err)
+	assert.Equal(t, 2, g.calls["Austin, TX"], "least recently used entry should have been evicted")
+	_, err = c.Lookup("Boston")
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.calls["Boston"])
+}
+
+func TestCacheBeforeGeocode(t *testing.T) {
+	c := NewCache(&countingGeocoder{}, time.Hour, 10)
+	assert.Nil(t, c.Latlong())
+	assert.Equal(t, "", c.ParsedLocation())
+}
+
+func TestCacheExpiry(t *testing.T) {
+	g := &countingGeocoder{remaining: 100}
+	c := NewCache(g, -time.Second, 10)
+	_, err := c.Lookup("austin")
+	require.NoError(t, err)
+	_, err = c.Lookup("austin")
+	require.NoError(t, err)
+	assert.Equal(t, 2, g.calls["austin"])
+}
+
+func TestCacheQuota(t *testing.T) {
+	g := &countingGeocoder{remaining: 3}
+	c := NewCache(g, time.Hour, 10)
+	c.Reserve = 1
+
+	_, err := c.Lookup("austin")
+	require.NoError(t, err)
+	remaining, _, ok := c.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 2, remaining)
+
+	_, err = c.Lookup("denver")
+	require.NoError(t, err)
+	_, err = c.Lookup("boston")
+	assert.Equal(t, ErrQuotaExhausted, errors.Cause(err))
+	assert.Equal(t, 0, g.calls["boston"])
+
+	_, err = c.Lookup("austin")
+	assert.NoError(t, err, "cached results are still served once the quota is exhausted")
+}
+
+func TestCacheQuotaAfterError(t *testing.T) {
+	g := &countingGeocoder{remaining: 2, err: errors.New("refused")}
+	c := NewCache(g, time.Hour, 10)
+	c.Reserve = 1
+
+	_, err := c.Lookup("austin")
+	assert.EqualError(t, err, "refused")
+	remaining, _, ok := c.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 1, remaining, "the quota is updated from failed requests too")
+
+	_, err = c.Lookup("denver")
+	assert.Equal(t, ErrQuotaExhausted, errors.Cause(err))
+	assert.Equal(t, 0, g.calls["denver"])
+}
+
+func TestCacheServesHitsDuringLookups(t *testing.T) {
+	g := &countingGeocoder{remaining: 100}
+	c := NewCache(g, time.Hour, 10)
+	_, err := c.Lookup("austin")
+	require.NoError(t, err)
+
+	g.started = make(chan string)
+	g.release = make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := c.Lookup("denver")
+		done <- err
+	}()
+	assert.Equal(t, "denver", <-g.started)
+
+	place, err := c.Lookup("austin")
+	require.NoError(t, err, "cached places don't wait for the geocoder")
+	assert.Equal(t, "austin", place.ParsedLocation)
+	_, _, ok := c.Remaining()
+	assert.True(t, ok)
+
+	close(g.release)
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, g.calls["denver"])
+}
+
+func TestCacheFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geocoding")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	g := &countingGeocoder{remaining: 100}
+	_, err = (&Cache{Geocoder: g, TTL: time.Hour, Store: &FileStore{Dir: dir}}).Lookup("austin")
+	require.NoError(t, err)
+
+	place, err := (&Cache{Geocoder: g, TTL: time.Hour, Store: &FileStore{Dir: dir}}).Lookup("Austin")
+	require.NoError(t, err)
+	assert.Equal(t, "austin", place.ParsedLocation)
+	assert.Equal(t, 1, g.calls["austin"]+g.calls["Austin"])
+
+	missing, err := (&FileStore{Dir: dir}).Load("nowhere")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
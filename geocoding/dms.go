@@ -0,0 +1,144 @@
+package geocoding
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const dmsBody = `(\d+(?:\.\d+)?)\s*(?:°\s*(?:(\d+(?:\.\d+)?)\s*'\s*(?:(\d+(?:\.\d+)?)\s*"\s*)?)?)?`
+
+// Each component is captured as sign, leading hemisphere, degrees, minutes,
+// seconds and trailing hemisphere. Hemispheres either lead both components
+// or trail both.
+const (
+	dmsLeading  = `([-+]?)([NSEW])\s*` + dmsBody + `()`
+	dmsTrailing = `([-+]?)()` + dmsBody + `([NSEW]?)`
+	dmsPair     = `^\s*%s\s*[,;/ ]?\s*%s\s*$`
+)
+
+var dmsPatterns = []*regexp.Regexp{
+	regexp.MustCompile(fmt.Sprintf(dmsPair, dmsLeading, dmsLeading)),
+	regexp.MustCompile(fmt.Sprintf(dmsPair, dmsTrailing, dmsTrailing)),
+}
+
+func matchDMS(s string) []string {
+	for _, p := range dmsPatterns {
+		if m := p.FindStringSubmatch(s); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// normalizeDMS folds the many ways of writing degree, minute and second
+// marks into °, ' and ".
+func normalizeDMS(s string) string {
+	return strings.NewReplacer(
+		"''", `"`, "′′", `"`, "″", `"`, "”", `"`,
+		"′", "'", "’", "'", "‘", "'",
+		"º", "°", "˚", "°",
+	).Replace(strings.ToUpper(s))
+}
+
+// IsDMS reports whether s looks like a pair of coordinates written with
+// degree/minute/second marks or hemisphere letters, such as
+// 30°16'16"N 97°44'37"W or 30.27N 97.74W.
+func IsDMS(s string) bool {
+	s = normalizeDMS(s)
+	return matchDMS(s) != nil && strings.ContainsAny(s, `°'"NSEW`)
+}
+
+// ParseDMS reads a latitude and longitude written in degrees, minutes and
+// seconds, as OpenCageData's DMS annotation and most maps print them.
+// Hemisphere letters may come before or after each component and decide
+// which component is the latitude; without them latitude comes first.
+func ParseDMS(s string) (*Coordinates, error) {
+	m := matchDMS(normalizeDMS(s))
+	if m == nil {
+		return nil, errors.Errorf("'%s' is not a DMS coordinate pair", s)
+	}
+	first, firstHemisphere, err := dmsValue(m[1:7])
+	if err != nil {
+		return nil, err
+	}
+	second, secondHemisphere, err := dmsValue(m[7:13])
+	if err != nil {
+		return nil, err
+	}
+
+	isLongitude := func(h string) bool { return h == "E" || h == "W" }
+	isLatitude := func(h string) bool { return h == "N" || h == "S" }
+	c := &Coordinates{Latitude: first, Longitude: second}
+	if isLongitude(firstHemisphere) || isLatitude(secondHemisphere) {
+		if isLongitude(secondHemisphere) || isLatitude(firstHemisphere) {
+			return nil, errors.Errorf("'%s' names the same axis twice", s)
+		}
+		c = &Coordinates{Latitude: second, Longitude: first}
+	}
+	if err = c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// dmsValue converts the sign, hemisphere, degrees, minutes, seconds and
+// trailing hemisphere captured for one component into decimal degrees.
+func dmsValue(m []string) (float64, string, error) {
+	hemisphere := m[1] + m[5]
+
+	value := 0.0
+	for i, unit := range []float64{1, 60, 3600} {
+		if m[2+i] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[2+i], 64)
+		if err != nil {
+			return 0, "", errors.Wrapf(err, "invalid number '%s'", m[2+i])
+		}
+		if i > 0 && v >= 60 {
+			return 0, "", errors.Errorf("%s is out of range for minutes or seconds", m[2+i])
+		}
+		value += v / unit
+	}
+
+	if m[0] == "-" || hemisphere == "S" || hemisphere == "W" {
+		value = -value
+	}
+	return value, hemisphere, nil
+}
+
+func (c *Coordinates) validate() error {
+	if c.Latitude < -90 || c.Latitude > 90 {
+		return errors.Errorf("latitude %f is out of range", c.Latitude)
+	}
+	if c.Longitude < -180 || c.Longitude > 180 {
+		return errors.Errorf("longitude %f is out of range", c.Longitude)
+	}
+	return nil
+}
+
+// DMS formats c in degrees, minutes and seconds with hemisphere letters,
+// e.g. 30° 16' 16.066" N, 97° 44' 37.318" W.
+func (c *Coordinates) DMS() string {
+	return fmt.Sprintf("%s, %s",
+		formatDMS(c.Latitude, "N", "S"),
+		formatDMS(c.Longitude, "E", "W"))
+}
+
+func formatDMS(value float64, positive, negative string) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+		value = -value
+	}
+	// round once, at the precision printed, so 59.9999" doesn't print as 60"
+	thousandths := int64(value*3600*1000 + 0.5)
+	degrees := thousandths / (3600 * 1000)
+	minutes := thousandths / (60 * 1000) % 60
+	seconds := float64(thousandths%(60*1000)) / 1000
+	return fmt.Sprintf(`%d° %d' %.3f" %s`, degrees, minutes, seconds, hemisphere)
+}
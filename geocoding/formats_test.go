@@ -0,0 +1,195 @@
+package geocoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertNear(t *testing.T, expected, actual *Coordinates, delta float64) {
+	t.Helper()
+	require.NotNil(t, actual)
+	assert.InDelta(t, expected.Latitude, actual.Latitude, delta, "latitude")
+	assert.InDelta(t, expected.Longitude, actual.Longitude, delta, "longitude")
+}
+
+var austin = &Coordinates{Latitude: 30.2711286, Longitude: -97.7436995}
+
+func TestParseDMS(t *testing.T) {
+	for _, s := range []string{
+		`30° 16' 16.06632'' N, 97° 44' 37.31820'' W`,
+		`30°16'16.066"N 97°44'37.318"W`,
+		`N30°16'16.066" W97°44'37.318"`,
+		`W97°44'37.318" N30°16'16.066"`,
+		`30°16′16.066″ -97°44′37.318″`,
+	} {
+		c, err := ParseDMS(s)
+		require.NoError(t, err, s)
+		assertNear(t, austin, c, 1e-5)
+		assert.True(t, IsDMS(s), s)
+	}
+
+	c, err := ParseDMS("30.2711N 97.7437W")
+	require.NoError(t, err)
+	assertNear(t, austin, c, 1e-4)
+
+	assert.False(t, IsDMS("30.27, -97.74"))
+	_, err = ParseDMS(`30°16'16"N 40°44'37"S`)
+	assert.Error(t, err)
+	_, err = ParseDMS(`30°75'16"N 97°44'37"W`)
+	assert.Error(t, err)
+}
+
+func TestParseGeohash(t *testing.T) {
+	c, err := ParseGeohash("9v6kpmr1")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 30.2635, Longitude: -97.7575}, c, 1e-3)
+
+	_, err = ParseGeohash("9v6ai")
+	assert.Error(t, err)
+}
+
+func TestParseMaidenhead(t *testing.T) {
+	c, err := ParseMaidenhead("EM10dg")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 30.2708, Longitude: -97.7083}, c, 1e-3)
+
+	c, err = ParseMaidenhead("JN58td")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 48.1458, Longitude: 11.625}, c, 1e-3)
+
+	assert.False(t, IsMaidenhead("ZZ10"))
+	_, err = ParseMaidenhead("EM1")
+	assert.Error(t, err)
+}
+
+func TestParsePlusCode(t *testing.T) {
+	// from the Open Location Code test data
+	c, err := ParsePlusCode("8FVC9G8F+6X")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 47.3655625, Longitude: 8.5249375}, c, 1e-7)
+
+	c, err = ParsePlusCode("8FVC0000+")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 47.5, Longitude: 8.5}, c, 1e-7)
+
+	assert.True(t, IsShortPlusCode("9G8F+6X"))
+	assert.False(t, IsPlusCode("9G8F+6X"))
+	assert.False(t, IsPlusCode("8FVC000+"))
+	_, err = ParsePlusCode("8FVC9G8F6X")
+	assert.Error(t, err)
+}
+
+func TestParseMGRS(t *testing.T) {
+	// the origin is the textbook check for zone and row letter handling
+	c, err := ParseMGRS("31NAA6602100000")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{}, c, 1e-4)
+
+	c, err = ParseMGRS("14R PU 20786 50551")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: 30.2806, Longitude: -97.7441}, c, 1e-4)
+
+	c, err = ParseMGRS("60HUD0000000000")
+	require.NoError(t, err)
+	assertNear(t, &Coordinates{Latitude: -37.9256, Longitude: 174.7245}, c, 1e-3)
+
+	assert.False(t, IsMGRS("14RPU123"))
+	_, err = ParseMGRS("61RPU2078650551")
+	assert.Error(t, err)
+}
+
+func TestDMSRoundTrip(t *testing.T) {
+	assert.Equal(t, `30° 16' 16.063" N, 97° 44' 37.318" W`, austin.DMS())
+	assert.Equal(t, `0° 0' 0.000" N, 0° 0' 0.000" E`, (&Coordinates{}).DMS())
+	assert.Equal(t, `33° 52' 0.000" S, 151° 13' 0.000" E`, (&Coordinates{Latitude: -33.866666666, Longitude: 151.216666666}).DMS())
+
+	c, err := ParseDMS(austin.DMS())
+	require.NoError(t, err)
+	assertNear(t, austin, c, 1e-6)
+}
+
+func TestGeohashRoundTrip(t *testing.T) {
+	// the example from geohash.org
+	assert.Equal(t, "u4pruydqqvj", (&Coordinates{Latitude: 57.64911, Longitude: 10.40744}).Geohash(11))
+
+	c, err := ParseGeohash(austin.Geohash(12))
+	require.NoError(t, err)
+	assertNear(t, austin, c, 1e-6)
+}
+
+func TestMaidenheadRoundTrip(t *testing.T) {
+	assert.Equal(t, "JN58td", (&Coordinates{Latitude: 48.14666, Longitude: 11.60833}).Maidenhead(6))
+	assert.Equal(t, "EM10", austin.Maidenhead(4))
+	assert.Equal(t, "EM10dg05", austin.Maidenhead(8))
+	assert.Equal(t, "RR99xx99", (&Coordinates{Latitude: 90, Longitude: 179.9999999}).Maidenhead(8))
+
+	c, err := ParseMaidenhead(austin.Maidenhead(8))
+	require.NoError(t, err)
+	assertNear(t, austin, c, 0.005)
+}
+
+func TestPlusCodeRoundTrip(t *testing.T) {
+	zurich := &Coordinates{Latitude: 47.365590, Longitude: 8.524997}
+	assert.Equal(t, "8FVC9G8F+6X", zurich.PlusCode(10))
+	assert.Equal(t, "8FVC9G8F+6XQ", zurich.PlusCode(11))
+	assert.Equal(t, "8FVC0000+", zurich.PlusCode(4))
+	assert.Equal(t, "CFX3X2X2+X2", (&Coordinates{Latitude: 90, Longitude: 1}).PlusCode(10))
+
+	c, err := ParsePlusCode(austin.PlusCode(11))
+	require.NoError(t, err)
+	assertNear(t, austin, c, 1e-4)
+
+	code, err := RecoverPlusCode("9G8F+6X", &Coordinates{Latitude: 47.4, Longitude: 8.6})
+	require.NoError(t, err)
+	assert.Equal(t, "8FVC9G8F+6X", code)
+
+	// the nearest match is across a 1° boundary from the reference
+	code, err = RecoverPlusCode("X2+X2", &Coordinates{Latitude: 47.01, Longitude: 8.01})
+	require.NoError(t, err)
+	assert.Equal(t, "8FRCX2X2+X2", code)
+
+	_, err = RecoverPlusCode("austin", austin)
+	assert.Error(t, err)
+}
+
+func TestMGRSRoundTrip(t *testing.T) {
+	reference, err := (&Coordinates{}).MGRS(5)
+	require.NoError(t, err)
+	assert.Equal(t, "31NAA6602100000", reference)
+
+	reference, err = (&Coordinates{Latitude: 38.8895, Longitude: -77.0352}).MGRS(5)
+	require.NoError(t, err)
+	assert.Equal(t, "18SUJ2348606483", reference)
+
+	reference, err = (&Coordinates{Latitude: 38.8895, Longitude: -77.0352}).MGRS(1)
+	require.NoError(t, err)
+	assert.Equal(t, "18SUJ20", reference)
+
+	// Norway and Svalbard zone exceptions
+	reference, err = (&Coordinates{Latitude: 60, Longitude: 5}).MGRS(0)
+	require.NoError(t, err)
+	assert.Equal(t, "32VKM", reference)
+	reference, err = (&Coordinates{Latitude: 78, Longitude: 15}).MGRS(0)
+	require.NoError(t, err)
+	assert.Equal(t, "33XWG", reference)
+
+	reference, err = austin.MGRS(5)
+	require.NoError(t, err)
+	c, err := ParseMGRS(reference)
+	require.NoError(t, err)
+	assertNear(t, austin, c, 1e-5)
+
+	_, err = (&Coordinates{Latitude: 85}).MGRS(5)
+	assert.Error(t, err)
+}
+
+func TestNotations(t *testing.T) {
+	n := austin.Notations()
+	assert.Equal(t, "14RPU2084149497", n.MGRS)
+	assert.Equal(t, "EM10dg05", n.Maidenhead)
+	assert.Equal(t, "9v6kpy9nner5", n.Geohash)
+	assert.Equal(t, "862477C4+FG3", n.PlusCode)
+	assert.Empty(t, (&Coordinates{Latitude: -89}).Notations().MGRS)
+}
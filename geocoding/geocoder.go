@@ -6,17 +6,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 type Geocoder interface {
+	Geocode(location string) error
 	Latlong() *Coordinates
 	ParsedLocation() string
 }
 
 var _ Geocoder = &OpenCageData{}
+var _ QuotaReporter = &OpenCageData{}
 
 type OpenCageData struct {
 	ApiURL string
@@ -24,7 +26,7 @@ type OpenCageData struct {
 }
 
 func NewOpenCageData(location, apiKey string) (*OpenCageData, error) {
-	o := &OpenCageData{ApiURL: fmt.Sprintf("https://api.opencagedata.com/geocode/v1/json?key=%s", apiKey)}
+	o := OpenCageDataWithKey(apiKey)
 	err := o.Geocode(location)
 	if err != nil {
 		return nil, err
@@ -32,7 +34,16 @@ func NewOpenCageData(location, apiKey string) (*OpenCageData, error) {
 	return o, nil
 }
 
+// OpenCageDataWithKey returns a geocoder that has not been queried yet, for
+// wrapping in a Cache.
+func OpenCageDataWithKey(apiKey string) *OpenCageData {
+	return &OpenCageData{ApiURL: fmt.Sprintf("https://api.opencagedata.com/geocode/v1/json?key=%s", apiKey)}
+}
+
 func (o *OpenCageData) Latlong() *Coordinates {
+	if !o.found() {
+		return nil
+	}
 	return &Coordinates{
 		Latitude:  o.Results[0].Geometry.Lat,
 		Longitude: o.Results[0].Geometry.Lng,
@@ -45,36 +56,25 @@ func (o *OpenCageData) Geocode(location string) (err error) {
 }
 
 func (o *OpenCageData) ParsedLocation() string {
-	result := o.Results[0].Components
-	if result.City != "" && result.State != "" {
-		if strings.ToUpper(result.CountryCode) == "US" {
-			return fmt.Sprintf("%s, %s", result.City, result.State)
-		} else {
-			return fmt.Sprintf("%s, %s, %s", result.City, result.State, result.Country)
-		}
-	}
-
-	if result.City != "" && result.State == "" {
-		if result.CountryCode == "us" || result.CountryCode == "" {
-			return fmt.Sprintf("%s", result.City)
-		}
-		if result.CountryCode != "us" {
-			return fmt.Sprintf("%s, %s", result.City, result.Country)
-		}
+	if !o.found() {
+		return ""
 	}
+	result := o.Results[0].Components
+	return formatPlace(result.City, result.State, result.Country, result.CountryCode)
+}
 
-	if result.State == "" {
-		return result.Country
-	}
-	if result.Country == "" {
-		return result.State
-	}
+// found reports whether the last query found anything.
+func (o *OpenCageData) found() bool {
+	return o.OpenCageDataGeocodeResponse != nil && len(o.Results) > 0
+}
 
-	if result.CountryCode != "us" {
-		return fmt.Sprintf("%s, %s", result.State, result.Country)
-	} else {
-		return fmt.Sprintf("%s", result.State)
+// Quota reports the rate limit from the last response. Paid accounts are
+// not rate limited and report nothing.
+func (o *OpenCageData) Quota() (remaining int, reset time.Time, ok bool) {
+	if o.OpenCageDataGeocodeResponse == nil || o.Rate.Limit == 0 {
+		return 0, time.Time{}, false
 	}
+	return o.Rate.Remaining, time.Unix(int64(o.Rate.Reset), 0), true
 }
 
 func (o *OpenCageData) Map(location string) string {
@@ -114,7 +114,9 @@ func (o *OpenCageData) doGeocode(location string) (*OpenCageDataGeocodeResponse,
 	}
 
 	if len(response.Results) == 0 {
-		return nil, errors.Errorf("no results found for location '%s'", location)
+		// Keep the response anyway for its rate limit, which is reported
+		// when requests are refused.
+		return response, errors.Errorf("no results found for location '%s'", location)
 	}
 
 	return response, nil
@@ -0,0 +1,234 @@
+package geocoding
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// EarthRadius is the mean radius of the earth in meters, used by the
+// spherical formulas below.
+const EarthRadius = 6371008.8
+
+func radians(degrees float64) float64 { return degrees * math.Pi / 180 }
+func degrees(radians float64) float64 { return radians * 180 / math.Pi }
+
+// normalizeLongitude wraps a longitude into [-180, 180).
+func normalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// DistanceTo returns the great circle distance in meters from c to o using
+// the haversine formula, which is accurate to about 0.5%.
+func (c *Coordinates) DistanceTo(o *Coordinates) float64 {
+	phi1, phi2 := radians(c.Latitude), radians(o.Latitude)
+	dPhi := phi2 - phi1
+	dLambda := radians(o.Longitude - c.Longitude)
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// VincentyDistanceTo returns the distance in meters from c to o on the WGS84
+// ellipsoid, accurate to within a millimeter. It fails for nearly antipodal
+// points, where the iteration doesn't converge; use DistanceTo there.
+func (c *Coordinates) VincentyDistanceTo(o *Coordinates) (float64, error) {
+	b := wgs84A * (1 - wgs84F)
+	l := radians(o.Longitude - c.Longitude)
+	u1 := math.Atan((1 - wgs84F) * math.Tan(radians(c.Latitude)))
+	u2 := math.Atan((1 - wgs84F) * math.Tan(radians(o.Latitude)))
+	sinU1, cosU1 := math.Sin(u1), math.Cos(u1)
+	sinU2, cosU2 := math.Sin(u2), math.Cos(u2)
+
+	lambda := l
+	var sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	for i := 0; ; i++ {
+		if i == 200 {
+			return 0, errors.New("Vincenty's formula failed to converge")
+		}
+		sinLambda, cosLambda := math.Sin(lambda), math.Cos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0, nil
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0
+		if cosSqAlpha != 0 {
+			// points on the equator have no defined cos2SigmaM
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		cc := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		previous := lambda
+		lambda = l + (1-cc)*wgs84F*sinAlpha*
+			(sigma+cc*sinSigma*(cos2SigmaM+cc*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < 1e-12 {
+			break
+		}
+	}
+
+	uSq := cosSqAlpha * (wgs84A*wgs84A - b*b) / (b * b)
+	a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	bb := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := bb * sinSigma * (cos2SigmaM + bb/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		bb/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	return b * a * (sigma - deltaSigma), nil
+}
+
+// BearingTo returns the initial great circle bearing from c to o in degrees
+// clockwise from north, in [0, 360).
+func (c *Coordinates) BearingTo(o *Coordinates) float64 {
+	phi1, phi2 := radians(c.Latitude), radians(o.Latitude)
+	dLambda := radians(o.Longitude - c.Longitude)
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns the point reached by travelling distance meters from c
+// along a great circle starting at bearing degrees.
+func (c *Coordinates) Destination(bearing, distance float64) *Coordinates {
+	phi1, lambda1 := radians(c.Latitude), radians(c.Longitude)
+	theta := radians(bearing)
+	delta := distance / EarthRadius
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+	return &Coordinates{
+		Latitude:  degrees(phi2),
+		Longitude: normalizeLongitude(degrees(lambda2)),
+	}
+}
+
+// BoundingBox is a latitude/longitude rectangle. A box crossing the
+// antimeridian has West greater than East.
+type BoundingBox struct {
+	South, West, North, East float64
+}
+
+// CrossesAntimeridian reports whether the box wraps past 180°.
+func (b *BoundingBox) CrossesAntimeridian() bool {
+	return b.West > b.East
+}
+
+// Contains reports whether c lies inside the box.
+func (b *BoundingBox) Contains(c *Coordinates) bool {
+	if c.Latitude < b.South || c.Latitude > b.North {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return c.Longitude >= b.West || c.Longitude <= b.East
+	}
+	return c.Longitude >= b.West && c.Longitude <= b.East
+}
+
+// BoundingBox returns the smallest box containing every point within radius
+// meters of c. Near a pole the box covers all longitudes.
+func (c *Coordinates) BoundingBox(radius float64) *BoundingBox {
+	delta := degrees(radius / EarthRadius)
+	box := &BoundingBox{
+		South: c.Latitude - delta,
+		North: c.Latitude + delta,
+	}
+	if box.North >= 90 || box.South <= -90 {
+		box.North = math.Min(box.North, 90)
+		box.South = math.Max(box.South, -90)
+		box.West, box.East = -180, 180
+		return box
+	}
+	// the widest point of a circle on a sphere is at the latitude where the
+	// meridians are tangent to it, not at c's latitude
+	dLon := degrees(math.Asin(math.Sin(radius/EarthRadius) / math.Cos(radians(c.Latitude))))
+	box.West = normalizeLongitude(c.Longitude - dLon)
+	box.East = normalizeLongitude(c.Longitude + dLon)
+	if box.East == -180 {
+		box.East = 180
+	}
+	return box
+}
+
+// Polygon is a closed ring of points, such as an NWS alert area. The last
+// point need not repeat the first.
+type Polygon []Coordinates
+
+// unwrapped returns the polygon's longitudes made continuous relative to its
+// first point, so rings crossing the antimeridian can be treated as planar.
+func (p Polygon) unwrapped() Polygon {
+	out := make(Polygon, len(p))
+	for i, c := range p {
+		out[i] = c
+		if i > 0 {
+			out[i].Longitude = p[0].Longitude + normalizeLongitude(c.Longitude-p[0].Longitude)
+		}
+	}
+	return out
+}
+
+// Area returns the area enclosed by the polygon in square meters on a
+// spherical earth.
+func (p Polygon) Area() float64 {
+	if len(p) < 3 {
+		return 0
+	}
+	sum := 0.0
+	for i := range p {
+		a, b := p[i], p[(i+1)%len(p)]
+		sum += radians(normalizeLongitude(b.Longitude-a.Longitude)) *
+			(2 + math.Sin(radians(a.Latitude)) + math.Sin(radians(b.Latitude)))
+	}
+	return math.Abs(sum * EarthRadius * EarthRadius / 2)
+}
+
+// Centroid returns the center of mass of the polygon treated as a flat
+// shape in latitude/longitude, which is accurate for areas the size of
+// weather alerts.
+func (p Polygon) Centroid() *Coordinates {
+	if len(p) == 0 {
+		return nil
+	}
+	q := p.unwrapped()
+	var area, lat, lon float64
+	for i := range q {
+		a, b := q[i], q[(i+1)%len(q)]
+		cross := a.Longitude*b.Latitude - b.Longitude*a.Latitude
+		area += cross
+		lon += (a.Longitude + b.Longitude) * cross
+		lat += (a.Latitude + b.Latitude) * cross
+	}
+	if area == 0 {
+		// degenerate polygon: average the points instead
+		for _, c := range q {
+			lat += c.Latitude
+			lon += c.Longitude
+		}
+		n := float64(len(q))
+		return &Coordinates{Latitude: lat / n, Longitude: normalizeLongitude(lon / n)}
+	}
+	area *= 3
+	return &Coordinates{Latitude: lat / area, Longitude: normalizeLongitude(lon / area)}
+}
+
+// Contains reports whether c lies inside the polygon, using the even-odd
+// rule on the flat latitude/longitude shape.
+func (p Polygon) Contains(c *Coordinates) bool {
+	if len(p) < 3 {
+		return false
+	}
+	q := p.unwrapped()
+	lon := q[0].Longitude + normalizeLongitude(c.Longitude-q[0].Longitude)
+	inside := false
+	for i, j := 0, len(q)-1; i < len(q); j, i = i, i+1 {
+		a, b := q[i], q[j]
+		if (a.Latitude > c.Latitude) != (b.Latitude > c.Latitude) &&
+			lon < (b.Longitude-a.Longitude)*(c.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,78 @@
+package geocoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Flinders Peak and Buninyong are the worked example in Vincenty's paper.
+var (
+	flindersPeak = &Coordinates{Latitude: -37.95103342, Longitude: 144.42486789}
+	buninyong    = &Coordinates{Latitude: -37.65282114, Longitude: 143.92649554}
+)
+
+func TestDistance(t *testing.T) {
+	d, err := flindersPeak.VincentyDistanceTo(buninyong)
+	require.NoError(t, err)
+	assert.InDelta(t, 54972.271, d, 0.001)
+	assert.InDelta(t, 54972.271, flindersPeak.DistanceTo(buninyong), 54972.271*0.005)
+
+	london := &Coordinates{Latitude: 51.5007, Longitude: -0.1246}
+	newYork := &Coordinates{Latitude: 40.6892, Longitude: -74.0445}
+	assert.InDelta(t, 5574840, london.DistanceTo(newYork), 1000)
+
+	d, err = austin.VincentyDistanceTo(austin)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, d)
+
+	_, err = (&Coordinates{Latitude: 0, Longitude: 0}).VincentyDistanceTo(&Coordinates{Latitude: 0.5, Longitude: 179.7})
+	assert.Error(t, err)
+}
+
+func TestBearingAndDestination(t *testing.T) {
+	assert.InDelta(t, 306.868, flindersPeak.BearingTo(buninyong), 0.2)
+	assert.InDelta(t, 90, (&Coordinates{}).BearingTo(&Coordinates{Longitude: 10}), 1e-9)
+	assert.InDelta(t, 180, (&Coordinates{Latitude: 10}).BearingTo(&Coordinates{}), 1e-9)
+
+	d := flindersPeak.Destination(flindersPeak.BearingTo(buninyong), flindersPeak.DistanceTo(buninyong))
+	assertNear(t, buninyong, d, 1e-9)
+
+	d = (&Coordinates{Longitude: 179.5}).Destination(90, 111195)
+	assertNear(t, &Coordinates{Longitude: -179.5}, d, 1e-3)
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := austin.BoundingBox(10000)
+	assert.InDelta(t, austin.Latitude-0.0899, box.South, 1e-4)
+	assert.InDelta(t, austin.Latitude+0.0899, box.North, 1e-4)
+	assert.InDelta(t, -97.8478, box.West, 1e-4)
+	assert.True(t, box.Contains(austin.Destination(45, 9999)))
+	assert.False(t, box.Contains(austin.Destination(0, 10100)))
+
+	box = (&Coordinates{Latitude: 0, Longitude: 179.95}).BoundingBox(10000)
+	assert.True(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(&Coordinates{Longitude: -179.99}))
+	assert.False(t, box.Contains(&Coordinates{Longitude: 0}))
+
+	box = (&Coordinates{Latitude: 89.95}).BoundingBox(10000)
+	assert.Equal(t, &BoundingBox{South: box.South, West: -180, North: 90, East: 180}, box)
+}
+
+func TestPolygon(t *testing.T) {
+	// a 1° square on the equator
+	square := Polygon{{0, 0}, {0, 1}, {1, 1}, {1, 0}}
+	assert.InDelta(t, 12364000000, square.Area(), 1e7)
+	assertNear(t, &Coordinates{Latitude: 0.5, Longitude: 0.5}, square.Centroid(), 1e-9)
+	assert.True(t, square.Contains(&Coordinates{Latitude: 0.5, Longitude: 0.5}))
+	assert.False(t, square.Contains(&Coordinates{Latitude: 1.5, Longitude: 0.5}))
+
+	wrapped := Polygon{{0, 179.5}, {0, -179.5}, {1, -179.5}, {1, 179.5}}
+	assert.InDelta(t, square.Area(), wrapped.Area(), 1)
+	assertNear(t, &Coordinates{Latitude: 0.5, Longitude: -180}, wrapped.Centroid(), 1e-9)
+	assert.True(t, wrapped.Contains(&Coordinates{Latitude: 0.5, Longitude: 179.9}))
+	assert.True(t, wrapped.Contains(&Coordinates{Latitude: 0.5, Longitude: -179.9}))
+
+	assert.Equal(t, 0.0, Polygon{{0, 0}, {1, 1}}.Area())
+}
@@ -0,0 +1,83 @@
+package geocoding
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// ParseGeohash decodes a geohash to the center of the cell it names.
+func ParseGeohash(hash string) (*Coordinates, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" {
+		return nil, errors.New("empty geohash")
+	}
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	even := true
+	for _, r := range hash {
+		idx := strings.IndexRune(geohashAlphabet, r)
+		if idx < 0 {
+			return nil, errors.Errorf("'%c' is not a geohash character", r)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := idx&(1<<uint(bit)) != 0
+			if even {
+				mid := (minLon + maxLon) / 2
+				if set {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return &Coordinates{
+		Latitude:  (minLat + maxLat) / 2,
+		Longitude: (minLon + maxLon) / 2,
+	}, nil
+}
+
+// Geohash encodes c as a geohash of the given number of characters; 9
+// characters is accurate to a few meters.
+func (c *Coordinates) Geohash(chars int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	hash := make([]byte, 0, chars)
+	even := true
+	for len(hash) < chars {
+		idx := 0
+		for bit := 4; bit >= 0; bit-- {
+			if even {
+				mid := (minLon + maxLon) / 2
+				if c.Longitude >= mid {
+					idx |= 1 << uint(bit)
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if c.Latitude >= mid {
+					idx |= 1 << uint(bit)
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+		hash = append(hash, geohashAlphabet[idx])
+	}
+	return string(hash)
+}
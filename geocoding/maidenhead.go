@@ -0,0 +1,87 @@
+package geocoding
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var maidenheadPattern = regexp.MustCompile(`^[A-Ra-r]{2}(?:[0-9]{2}(?:[A-Xa-x]{2}(?:[0-9]{2})?)?)?$`)
+
+// IsMaidenhead reports whether s is a Maidenhead locator of 2 to 8
+// characters, such as EM10dg.
+func IsMaidenhead(s string) bool {
+	return maidenheadPattern.MatchString(s)
+}
+
+// ParseMaidenhead decodes a Maidenhead grid locator to the center of the
+// square it names.
+func ParseMaidenhead(locator string) (*Coordinates, error) {
+	if !IsMaidenhead(locator) {
+		return nil, errors.Errorf("'%s' is not a Maidenhead locator", locator)
+	}
+	locator = strings.ToUpper(locator)
+
+	lon, lat := -180.0, -90.0
+	lonSize, latSize := 20.0, 10.0
+	for i := 0; i < len(locator); i += 2 {
+		var base byte = 'A'
+		if i == 2 || i == 6 {
+			base = '0'
+		}
+		if i > 0 {
+			divisions := 24.0
+			if i == 2 || i == 6 {
+				divisions = 10
+			}
+			lonSize /= divisions
+			latSize /= divisions
+		}
+		lon += float64(locator[i]-base) * lonSize
+		lat += float64(locator[i+1]-base) * latSize
+	}
+	return &Coordinates{Latitude: lat + latSize/2, Longitude: lon + lonSize/2}, nil
+}
+
+// Maidenhead returns the Maidenhead locator of the square containing c,
+// with 2, 4, 6 or 8 characters.
+func (c *Coordinates) Maidenhead(chars int) string {
+	if chars < 2 {
+		chars = 2
+	}
+	if chars > 8 {
+		chars = 8
+	}
+	chars -= chars % 2
+
+	lon := math.Mod(c.Longitude+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	lat := math.Min(math.Max(c.Latitude+90, 0), 180-1e-9)
+
+	locator := make([]byte, 0, chars)
+	lonSize, latSize := 20.0, 10.0
+	for i := 0; i < chars; i += 2 {
+		var base byte = 'A'
+		divisions := 24.0
+		if i == 2 || i == 6 {
+			base = '0'
+			divisions = 10
+		}
+		if i > 0 {
+			lonSize /= divisions
+			latSize /= divisions
+		}
+		if i == 4 {
+			base = 'a'
+		}
+		lonIdx, latIdx := math.Floor(lon/lonSize), math.Floor(lat/latSize)
+		locator = append(locator, base+byte(lonIdx), base+byte(latIdx))
+		lon -= lonIdx * lonSize
+		lat -= latIdx * latSize
+	}
+	return string(locator)
+}
@@ -68,36 +68,18 @@ func (*SlippyMapTile) Num2deg(t *SlippyMapTile) (lat float64, long float64) {
 	return lat, long
 }
 
+// CoordsToSlippyMapTiles returns the 2x2 block of tiles at zoom centered as
+// closely as possible on coords, ordered top left, top right, bottom left,
+// bottom right. The tile containing coords is the one chosen by Corner.
+// Use TileGrid for other grid sizes.
 func CoordsToSlippyMapTiles(coords *Coordinates, zoom int) [4]*SlippyMapTile {
 	tile := CoordinatesToTile(coords, zoom)
 	var tiles [4]*SlippyMapTile
-	corner := tile.Corner()
-	switch corner {
-	case TopLeft:
-		tiles = [4]*SlippyMapTile{
-			tile, {X: tile.X + 1, Y: tile.Y},
-			{X: tile.X, Y: tile.Y + 1}, {X: tile.X + 1, Y: tile.Y + 1},
-		}
-	case TopRight:
-		tiles = [4]*SlippyMapTile{
-			{X: tile.X - 1, Y: tile.Y}, tile,
-			{X: tile.X - 1, Y: tile.Y + 1}, {X: tile.X, Y: tile.Y + 1},
-		}
-	case BottomLeft:
-		tiles = [4]*SlippyMapTile{
-			{X: tile.X, Y: tile.Y - 1}, {X: tile.X + 1, Y: tile.Y - 1},
-			tile, {X: tile.X + 1, Y: tile.Y},
-		}
-	case BottomRight:
-		tiles = [4]*SlippyMapTile{
-			{X: tile.X - 1, Y: tile.Y - 1}, {X: tile.X, Y: tile.Y - 1},
-			{X: tile.X - 1, Y: tile.Y}, tile,
+	for i, t := range TileGrid(coords, zoom, 2, 2).Tiles() {
+		if t.X == tile.X && t.Y == tile.Y {
+			t = tile
 		}
+		tiles[i] = t
 	}
-
-	for _, t := range tiles {
-		t.Z = zoom
-	}
-
 	return tiles
 }
@@ -0,0 +1,217 @@
+package geocoding
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WGS84 ellipsoid and UTM projection parameters.
+const (
+	wgs84A          = 6378137.0
+	wgs84F          = 1 / 298.257223563
+	utmScale        = 0.9996
+	utmFalseEasting = 500000.0
+	utmFalseNorth   = 10000000.0
+)
+
+const (
+	mgrsBands     = "CDEFGHJKLMNPQRSTUVWX"
+	mgrsRowLetter = "ABCDEFGHJKLMNPQRSTUV"
+)
+
+// mgrsColumnLetters are the 100 km column letters, which repeat every three
+// UTM zones.
+var mgrsColumnLetters = [3]string{"ABCDEFGH", "JKLMNPQR", "STUVWXYZ"}
+
+// mgrsBandNorthing is the smallest UTM northing in each latitude band, used
+// to work out which 2000 km cycle of row letters a grid reference is in.
+var mgrsBandNorthing = map[byte]float64{
+	'C': 1100000, 'D': 2000000, 'E': 2800000, 'F': 3700000, 'G': 4600000,
+	'H': 5500000, 'J': 6400000, 'K': 7300000, 'L': 8200000, 'M': 9100000,
+	'N': 0, 'P': 800000, 'Q': 1700000, 'R': 2600000, 'S': 3500000,
+	'T': 4400000, 'U': 5300000, 'V': 6200000, 'W': 7000000, 'X': 7900000,
+}
+
+var mgrsPattern = regexp.MustCompile(`^(\d{1,2})([C-HJ-NP-X])\s*([A-HJ-NP-Z])([A-HJ-NP-V])\s*(\d*)\s*(\d*)$`)
+
+// IsMGRS reports whether s is a Military Grid Reference System coordinate
+// such as 14RPU2078650551 or 14R PU 20786 50551.
+func IsMGRS(s string) bool {
+	m := mgrsPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil {
+		return false
+	}
+	digits := m[5] + m[6]
+	return len(digits)%2 == 0 && len(digits) <= 10
+}
+
+// ParseMGRS decodes an MGRS grid reference to the center of the square it
+// names.
+func ParseMGRS(s string) (*Coordinates, error) {
+	m := mgrsPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil || !IsMGRS(s) {
+		return nil, errors.Errorf("'%s' is not an MGRS grid reference", s)
+	}
+	zone, _ := strconv.Atoi(m[1])
+	if zone < 1 || zone > 60 {
+		return nil, errors.Errorf("UTM zone %d is out of range", zone)
+	}
+	band := m[2][0]
+
+	columns := mgrsColumnLetters[(zone-1)%3]
+	col := strings.IndexByte(columns, m[3][0])
+	if col < 0 {
+		return nil, errors.Errorf("column letter %s is not used in zone %d", m[3], zone)
+	}
+	row := strings.IndexByte(mgrsRowLetter, m[4][0])
+	if zone%2 == 0 {
+		row = (row + len(mgrsRowLetter) - 5) % len(mgrsRowLetter)
+	}
+
+	digits := m[5] + m[6]
+	precision := len(digits) / 2
+	resolution := math.Pow10(5 - precision)
+	easting, northing := 0.0, 0.0
+	if precision > 0 {
+		e, _ := strconv.Atoi(digits[:precision])
+		n, _ := strconv.Atoi(digits[precision:])
+		easting, northing = float64(e)*resolution, float64(n)*resolution
+	}
+	easting += float64(col+1)*100000 + resolution/2
+	northing += float64(row)*100000 + resolution/2
+	for northing < mgrsBandNorthing[band] {
+		northing += 2000000
+	}
+
+	return utmToCoordinates(zone, band >= 'N', easting, northing), nil
+}
+
+// utmToCoordinates inverts the transverse Mercator projection using the
+// series expansion in Snyder, "Map Projections: A Working Manual", p. 63.
+func utmToCoordinates(zone int, north bool, easting, northing float64) *Coordinates {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	x := easting - utmFalseEasting
+	y := northing
+	if !north {
+		y -= utmFalseNorth
+	}
+
+	mu := y / utmScale / (wgs84A * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu +
+		(3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sin, cos, tan := math.Sin(phi1), math.Cos(phi1), math.Tan(phi1)
+	n1 := wgs84A / math.Sqrt(1-e2*sin*sin)
+	t1 := tan * tan
+	c1 := ep2 * cos * cos
+	r1 := wgs84A * (1 - e2) / math.Pow(1-e2*sin*sin, 1.5)
+	d := x / (n1 * utmScale)
+
+	lat := phi1 - (n1*tan/r1)*(d*d/2-
+		(5+3*t1+10*c1-4*c1*c1-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t1+298*c1+45*t1*t1-252*ep2-3*c1*c1)*math.Pow(d, 6)/720)
+	lon := (d - (1+2*t1+c1)*math.Pow(d, 3)/6 +
+		(5-2*c1+28*t1-3*c1*c1+8*ep2+24*t1*t1)*math.Pow(d, 5)/120) / cos
+
+	return &Coordinates{
+		Latitude:  lat * 180 / math.Pi,
+		Longitude: utmCentralMeridian(zone) + lon*180/math.Pi,
+	}
+}
+
+func utmCentralMeridian(zone int) float64 {
+	return float64(zone-1)*6 - 180 + 3
+}
+
+// UTM returns the Universal Transverse Mercator zone, latitude band and
+// easting and northing in meters of c, including the Norway and Svalbard
+// zone exceptions. UTM is undefined south of 80°S and north of 84°N.
+func (c *Coordinates) UTM() (zone int, band byte, easting, northing float64, err error) {
+	if c.Latitude < -80 || c.Latitude > 84 {
+		return 0, 0, 0, 0, errors.Errorf("latitude %f is outside the UTM grid", c.Latitude)
+	}
+	lon := math.Mod(c.Longitude+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	lon -= 180
+	zone = int((lon+180)/6) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	bandIdx := int((c.Latitude + 80) / 8)
+	if bandIdx >= len(mgrsBands) {
+		bandIdx = len(mgrsBands) - 1
+	}
+	band = mgrsBands[bandIdx]
+
+	switch {
+	case band == 'V' && lon >= 3 && lon < 12:
+		zone = 32
+	case band == 'X' && lon >= 0 && lon < 42:
+		zone = 31 + 2*int((lon+3)/12)
+	}
+
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	phi := c.Latitude * math.Pi / 180
+	sin, cos, tan := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	n := wgs84A / math.Sqrt(1-e2*sin*sin)
+	t := tan * tan
+	cc := ep2 * cos * cos
+	a := cos * (lon - utmCentralMeridian(zone)) * math.Pi / 180
+	m := wgs84A * ((1-e2/4-3*e2*e2/64-5*e2*e2*e2/256)*phi -
+		(3*e2/8+3*e2*e2/32+45*e2*e2*e2/1024)*math.Sin(2*phi) +
+		(15*e2*e2/256+45*e2*e2*e2/1024)*math.Sin(4*phi) -
+		(35*e2*e2*e2/3072)*math.Sin(6*phi))
+
+	easting = utmScale*n*(a+(1-t+cc)*math.Pow(a, 3)/6+
+		(5-18*t+t*t+72*cc-58*ep2)*math.Pow(a, 5)/120) + utmFalseEasting
+	northing = utmScale * (m + n*tan*(a*a/2+
+		(5-t+9*cc+4*cc*cc)*math.Pow(a, 4)/24+
+		(61-58*t+t*t+600*cc-330*ep2)*math.Pow(a, 6)/720))
+	if c.Latitude < 0 {
+		northing += utmFalseNorth
+	}
+	return zone, band, easting, northing, nil
+}
+
+// MGRS returns the grid reference of the square containing c, with digits
+// digits each for easting and northing: 5 is 1 m precision, 1 is 10 km.
+func (c *Coordinates) MGRS(digits int) (string, error) {
+	if digits < 0 || digits > 5 {
+		return "", errors.Errorf("MGRS precision must be 0 to 5 digits, not %d", digits)
+	}
+	zone, band, easting, northing, err := c.UTM()
+	if err != nil {
+		return "", err
+	}
+	col := int(easting/100000) - 1
+	columns := mgrsColumnLetters[(zone-1)%3]
+	if col < 0 || col >= len(columns) {
+		return "", errors.Errorf("easting %f is outside zone %d", easting, zone)
+	}
+	row := int(northing/100000) % len(mgrsRowLetter)
+	if zone%2 == 0 {
+		row = (row + 5) % len(mgrsRowLetter)
+	}
+
+	divisor := math.Pow10(5 - digits)
+	e := int(math.Mod(easting, 100000) / divisor)
+	n := int(math.Mod(northing, 100000) / divisor)
+	reference := fmt.Sprintf("%02d%c%c%c", zone, band, columns[col], mgrsRowLetter[row])
+	if digits > 0 {
+		reference += fmt.Sprintf("%0*d%0*d", digits, e, digits, n)
+	}
+	return reference, nil
+}
@@ -0,0 +1,133 @@
+package geocoding
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NominatimURL is the public OpenStreetMap Nominatim instance. Point
+// Nominatim.ApiURL at a self-hosted instance to avoid its usage limits.
+const NominatimURL = "https://nominatim.openstreetmap.org"
+
+var _ Geocoder = &Nominatim{}
+
+// Nominatim geocodes against the Nominatim search and reverse APIs. It needs
+// no API key, but every request carries UserAgent and requests to the same
+// host are spaced RequestInterval apart, as the usage policy requires.
+type Nominatim struct {
+	ApiURL    string
+	UserAgent string
+	Client    *http.Client
+	*NominatimPlace
+}
+
+type NominatimPlace struct {
+	PlaceID     int64    `json:"place_id"`
+	OSMType     string   `json:"osm_type"`
+	OSMID       int64    `json:"osm_id"`
+	Lat         float64  `json:"lat,string"`
+	Lon         float64  `json:"lon,string"`
+	DisplayName string   `json:"display_name"`
+	Category    string   `json:"category"`
+	Type        string   `json:"type"`
+	BoundingBox []string `json:"boundingbox"`
+	Address     struct {
+		City         string `json:"city"`
+		Town         string `json:"town"`
+		Village      string `json:"village"`
+		Hamlet       string `json:"hamlet"`
+		Municipality string `json:"municipality"`
+		County       string `json:"county"`
+		State        string `json:"state"`
+		Postcode     string `json:"postcode"`
+		Country      string `json:"country"`
+		CountryCode  string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func NewNominatim(location string) (*Nominatim, error) {
+	n := &Nominatim{ApiURL: NominatimURL}
+	err := n.Geocode(location)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *Nominatim) Geocode(location string) error {
+	q := url.Values{}
+	q.Set("q", location)
+	q.Set("format", "jsonv2")
+	q.Set("addressdetails", "1")
+	q.Set("limit", "1")
+
+	places := []*NominatimPlace{}
+	if err := getThrottledJSON(n.Client, n.endpoint("/search", q), n.UserAgent, &places); err != nil {
+		return errors.Wrapf(err, "failed to fetch coordinates for location %s", location)
+	}
+	if len(places) == 0 {
+		return errors.Errorf("no results found for location '%s'", location)
+	}
+	n.NominatimPlace = places[0]
+	return nil
+}
+
+// Reverse looks up the place at coords.
+func (n *Nominatim) Reverse(coords *Coordinates) error {
+	q := url.Values{}
+	q.Set("lat", fmt.Sprintf("%f", coords.Latitude))
+	q.Set("lon", fmt.Sprintf("%f", coords.Longitude))
+	q.Set("format", "jsonv2")
+	q.Set("addressdetails", "1")
+
+	place := new(NominatimPlace)
+	if err := getThrottledJSON(n.Client, n.endpoint("/reverse", q), n.UserAgent, place); err != nil {
+		return errors.Wrapf(err, "failed to reverse geocode %f,%f", coords.Latitude, coords.Longitude)
+	}
+	if place.Error != "" {
+		return errors.Errorf("no results found for %f,%f: %s", coords.Latitude, coords.Longitude, place.Error)
+	}
+	n.NominatimPlace = place
+	return nil
+}
+
+func (n *Nominatim) Latlong() *Coordinates {
+	if n.NominatimPlace == nil {
+		return nil
+	}
+	return &Coordinates{
+		Latitude:  n.Lat,
+		Longitude: n.Lon,
+	}
+}
+
+func (n *Nominatim) ParsedLocation() string {
+	if n.NominatimPlace == nil {
+		return ""
+	}
+	a := n.Address
+	return formatPlace(firstNonEmpty(a.City, a.Town, a.Village, a.Hamlet, a.Municipality),
+		a.State, a.Country, a.CountryCode)
+}
+
+func (n *Nominatim) endpoint(path string, q url.Values) string {
+	base := n.ApiURL
+	if base == "" {
+		base = NominatimURL
+	}
+	return fmt.Sprintf("%s%s?%s", strings.TrimRight(base, "/"), path, q.Encode())
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package geocoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nominatimSearchResponse = `[{
+	"place_id": 282973488,
+	"osm_type": "relation",
+	"osm_id": 113314,
+	"lat": "30.2711286",
+	"lon": "-97.7436995",
+	"display_name": "Austin, Travis County, Texas, United States",
+	"category": "boundary",
+	"type": "administrative",
+	"boundingbox": ["30.0986589", "30.5168629", "-97.9383829", "-97.5614889"],
+	"address": {
+		"city": "Austin",
+		"county": "Travis County",
+		"state": "Texas",
+		"country": "United States",
+		"country_code": "us"
+	}
+}]`
+
+const nominatimReverseResponse = `{
+	"place_id": 1234,
+	"lat": "48.8582602",
+	"lon": "2.2944990",
+	"display_name": "Tour Eiffel, Paris, France",
+	"address": {
+		"town": "Paris",
+		"state": "Île-de-France",
+		"country": "France",
+		"country_code": "fr"
+	}
+}`
+
+func fakeNominatim(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "weather-tests", r.Header.Get("User-Agent"))
+		assert.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+		switch r.URL.Path {
+		case "/search":
+			if r.URL.Query().Get("q") == "nowhere" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			assert.Equal(t, "austin, tx", r.URL.Query().Get("q"))
+			w.Write([]byte(nominatimSearchResponse))
+		case "/reverse":
+			if r.URL.Query().Get("lat") == "0.000000" {
+				w.Write([]byte(`{"error": "Unable to geocode"}`))
+				return
+			}
+			assert.Equal(t, "48.858260", r.URL.Query().Get("lat"))
+			assert.Equal(t, "2.294499", r.URL.Query().Get("lon"))
+			w.Write([]byte(nominatimReverseResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestNominatimGeocode(t *testing.T) {
+	server := fakeNominatim(t)
+	defer server.Close()
+
+	n := &Nominatim{ApiURL: server.URL, UserAgent: "weather-tests"}
+	require.NoError(t, n.Geocode("austin, tx"))
+	assert.Equal(t, &Coordinates{Latitude: 30.2711286, Longitude: -97.7436995}, n.Latlong())
+	assert.Equal(t, "Austin, Texas", n.ParsedLocation())
+
+	assert.Error(t, n.Geocode("nowhere"))
+}
+
+func TestNominatimReverse(t *testing.T) {
+	server := fakeNominatim(t)
+	defer server.Close()
+
+	n := &Nominatim{ApiURL: server.URL, UserAgent: "weather-tests"}
+	require.NoError(t, n.Reverse(&Coordinates{Latitude: 48.8582602, Longitude: 2.294499}))
+	assert.Equal(t, 48.8582602, n.Latlong().Latitude)
+	assert.Equal(t, "Paris, Île-de-France, France", n.ParsedLocation())
+
+	assert.Error(t, n.Reverse(&Coordinates{}))
+}
+
+func TestThrottle(t *testing.T) {
+	defer func(interval time.Duration) { RequestInterval = interval }(RequestInterval)
+	RequestInterval = 50 * time.Millisecond
+
+	th := new(throttle)
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			th.wait()
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+}
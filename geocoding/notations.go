@@ -0,0 +1,24 @@
+package geocoding
+
+// Notations holds a point written in each of the coordinate formats this
+// package understands, the same set OpenCageData annotates its results with.
+type Notations struct {
+	DMS        string
+	MGRS       string
+	Maidenhead string
+	Geohash    string
+	PlusCode   string
+}
+
+// Notations writes c in every supported format at the precision OpenCageData
+// uses. MGRS is left empty near the poles, where it is undefined.
+func (c *Coordinates) Notations() *Notations {
+	mgrs, _ := c.MGRS(5)
+	return &Notations{
+		DMS:        c.DMS(),
+		MGRS:       mgrs,
+		Maidenhead: c.Maidenhead(8),
+		Geohash:    c.Geohash(12),
+		PlusCode:   c.PlusCode(11),
+	}
+}
@@ -0,0 +1,134 @@
+package geocoding
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PhotonURL is the public Photon instance run by Komoot.
+const PhotonURL = "https://photon.komoot.io"
+
+var _ Geocoder = &Photon{}
+
+// Photon geocodes against the Photon search and reverse APIs, which serve
+// OpenStreetMap data without an API key. It shares Nominatim's User-Agent
+// and throttling behavior.
+type Photon struct {
+	ApiURL    string
+	UserAgent string
+	Client    *http.Client
+	*PhotonFeature
+}
+
+type PhotonResponse struct {
+	Features []*PhotonFeature `json:"features"`
+}
+
+type PhotonFeature struct {
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		OSMID       int64     `json:"osm_id"`
+		OSMType     string    `json:"osm_type"`
+		OSMKey      string    `json:"osm_key"`
+		OSMValue    string    `json:"osm_value"`
+		Type        string    `json:"type"`
+		Name        string    `json:"name"`
+		City        string    `json:"city"`
+		County      string    `json:"county"`
+		State       string    `json:"state"`
+		Postcode    string    `json:"postcode"`
+		Country     string    `json:"country"`
+		CountryCode string    `json:"countrycode"`
+		Extent      []float64 `json:"extent"`
+	} `json:"properties"`
+}
+
+func NewPhoton(location string) (*Photon, error) {
+	p := &Photon{ApiURL: PhotonURL}
+	err := p.Geocode(location)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Photon) Geocode(location string) error {
+	q := url.Values{}
+	q.Set("q", location)
+	q.Set("limit", "1")
+	if err := p.fetch("/api", q); err != nil {
+		return errors.Wrapf(err, "failed to fetch coordinates for location %s", location)
+	}
+	if p.PhotonFeature == nil {
+		return errors.Errorf("no results found for location '%s'", location)
+	}
+	return nil
+}
+
+// Reverse looks up the place at coords.
+func (p *Photon) Reverse(coords *Coordinates) error {
+	q := url.Values{}
+	q.Set("lat", fmt.Sprintf("%f", coords.Latitude))
+	q.Set("lon", fmt.Sprintf("%f", coords.Longitude))
+	q.Set("limit", "1")
+	if err := p.fetch("/reverse", q); err != nil {
+		return errors.Wrapf(err, "failed to reverse geocode %f,%f", coords.Latitude, coords.Longitude)
+	}
+	if p.PhotonFeature == nil {
+		return errors.Errorf("no results found for %f,%f", coords.Latitude, coords.Longitude)
+	}
+	return nil
+}
+
+func (p *Photon) fetch(path string, q url.Values) error {
+	base := p.ApiURL
+	if base == "" {
+		base = PhotonURL
+	}
+	response := new(PhotonResponse)
+	err := getThrottledJSON(p.Client,
+		fmt.Sprintf("%s%s?%s", strings.TrimRight(base, "/"), path, q.Encode()),
+		p.UserAgent, response)
+	if err != nil {
+		return err
+	}
+	p.PhotonFeature = nil
+	for _, f := range response.Features {
+		if len(f.Geometry.Coordinates) >= 2 {
+			p.PhotonFeature = f
+			break
+		}
+	}
+	return nil
+}
+
+// Latlong returns the feature's point; GeoJSON orders it longitude first.
+// It returns nil if nothing has been found.
+func (p *Photon) Latlong() *Coordinates {
+	if p.PhotonFeature == nil {
+		return nil
+	}
+	return &Coordinates{
+		Latitude:  p.Geometry.Coordinates[1],
+		Longitude: p.Geometry.Coordinates[0],
+	}
+}
+
+func (p *Photon) ParsedLocation() string {
+	if p.PhotonFeature == nil {
+		return ""
+	}
+	props := p.Properties
+	city := props.City
+	if city == "" && props.Type == "city" {
+		city = props.Name
+	}
+	return formatPlace(city, props.State, props.Country, props.CountryCode)
+}
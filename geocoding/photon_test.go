@@ -0,0 +1,62 @@
+package geocoding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const photonResponse = `{
+	"type": "FeatureCollection",
+	"features": [{
+		"type": "Feature",
+		"geometry": {"type": "Point", "coordinates": [-97.7436995, 30.2711286]},
+		"properties": {
+			"osm_id": 113314,
+			"osm_type": "R",
+			"osm_key": "place",
+			"osm_value": "city",
+			"type": "city",
+			"name": "Austin",
+			"county": "Travis County",
+			"state": "Texas",
+			"country": "United States",
+			"countrycode": "US"
+		}
+	}]
+}`
+
+func TestPhoton(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DefaultUserAgent, r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/api":
+			if r.URL.Query().Get("q") == "nowhere" {
+				w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+				return
+			}
+			assert.Equal(t, "austin", r.URL.Query().Get("q"))
+		case "/reverse":
+			assert.Equal(t, "30.271129", r.URL.Query().Get("lat"))
+			assert.Equal(t, "-97.743700", r.URL.Query().Get("lon"))
+		}
+		w.Write([]byte(photonResponse))
+	}))
+	defer server.Close()
+
+	p := &Photon{ApiURL: server.URL}
+	assert.Nil(t, p.Latlong(), "nothing has been found yet")
+	assert.Equal(t, "", p.ParsedLocation())
+	require.NoError(t, p.Geocode("austin"))
+	assert.Equal(t, &Coordinates{Latitude: 30.2711286, Longitude: -97.7436995}, p.Latlong())
+	assert.Equal(t, "Austin, Texas", p.ParsedLocation())
+
+	require.NoError(t, p.Reverse(&Coordinates{Latitude: 30.2711286, Longitude: -97.7436995}))
+	assert.Equal(t, "Austin, Texas", p.ParsedLocation())
+
+	assert.Error(t, p.Geocode("nowhere"))
+	assert.Nil(t, p.Latlong())
+}
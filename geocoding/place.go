@@ -0,0 +1,38 @@
+package geocoding
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formatPlace renders the components of a geocoding result the same way
+// regardless of which service produced them: "City, State" inside the US
+// and "City, State, Country" everywhere else, dropping whatever is missing.
+func formatPlace(city, state, country, countryCode string) string {
+	countryCode = strings.ToLower(countryCode)
+	if city != "" && state != "" {
+		if countryCode == "us" {
+			return fmt.Sprintf("%s, %s", city, state)
+		}
+		return fmt.Sprintf("%s, %s, %s", city, state, country)
+	}
+
+	if city != "" && state == "" {
+		if countryCode == "us" || countryCode == "" {
+			return city
+		}
+		return fmt.Sprintf("%s, %s", city, country)
+	}
+
+	if state == "" {
+		return country
+	}
+	if country == "" {
+		return state
+	}
+
+	if countryCode != "us" {
+		return fmt.Sprintf("%s, %s", state, country)
+	}
+	return state
+}
@@ -0,0 +1,183 @@
+package geocoding
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Open Location Code constants, from the specification at
+// https://github.com/google/open-location-code/blob/main/docs/specification.md
+const (
+	plusCodeAlphabet      = "23456789CFGHJMPQRVWX"
+	plusCodeSeparator     = '+'
+	plusCodeSeparatorPos  = 8
+	plusCodePairLength    = 10
+	plusCodeGridColumns   = 4
+	plusCodeGridRows      = 5
+	plusCodeEncodingBase  = 20.0
+	plusCodeLatitudeMax   = 90.0
+	plusCodeLongitudeMax  = 180.0
+	plusCodeMaxCodeLength = 15
+)
+
+var (
+	fullPlusCodePattern  = regexp.MustCompile(`^[23456789CFGHJMPQRVWX]{8}\+(?:[23456789CFGHJMPQRVWX]{2,7})?$|^[23456789CFGHJMPQRVWX]{2,6}0+\+$`)
+	shortPlusCodePattern = regexp.MustCompile(`^[23456789CFGHJMPQRVWX]{2,6}\+[23456789CFGHJMPQRVWX]{2,7}$`)
+)
+
+// IsPlusCode reports whether code is a full (global) Plus Code such as
+// 862483V3+8C.
+func IsPlusCode(code string) bool {
+	code = strings.ToUpper(code)
+	return fullPlusCodePattern.MatchString(code) &&
+		strings.IndexByte(code, plusCodeSeparator) == plusCodeSeparatorPos &&
+		len(strings.TrimRight(code[:plusCodeSeparatorPos], "0"))%2 == 0
+}
+
+// IsShortPlusCode reports whether code is a Plus Code with leading digits
+// removed, such as V3+8C, which only identifies a place relative to a
+// nearby reference location.
+func IsShortPlusCode(code string) bool {
+	return shortPlusCodePattern.MatchString(strings.ToUpper(code))
+}
+
+// ParsePlusCode decodes a full Plus Code to the center of its area.
+func ParsePlusCode(code string) (*Coordinates, error) {
+	south, west, latSize, lonSize, err := decodePlusCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinates{
+		Latitude:  south + latSize/2,
+		Longitude: west + lonSize/2,
+	}, nil
+}
+
+// decodePlusCode returns the southwest corner and size of the area a full
+// Plus Code names.
+func decodePlusCode(code string) (south, west, latSize, lonSize float64, err error) {
+	code = strings.ToUpper(code)
+	if !IsPlusCode(code) {
+		return 0, 0, 0, 0, errors.Errorf("'%s' is not a full Plus Code", code)
+	}
+	digits := strings.TrimRight(strings.Replace(code, string(plusCodeSeparator), "", 1), "0")
+	if len(digits) > plusCodeMaxCodeLength {
+		digits = digits[:plusCodeMaxCodeLength]
+	}
+
+	south, west = -plusCodeLatitudeMax, -plusCodeLongitudeMax
+	latSize, lonSize = plusCodeEncodingBase*plusCodeEncodingBase, plusCodeEncodingBase*plusCodeEncodingBase
+	for i := 0; i < len(digits) && i < plusCodePairLength; i += 2 {
+		latSize /= plusCodeEncodingBase
+		lonSize /= plusCodeEncodingBase
+		south += float64(strings.IndexByte(plusCodeAlphabet, digits[i])) * latSize
+		if i+1 < len(digits) {
+			west += float64(strings.IndexByte(plusCodeAlphabet, digits[i+1])) * lonSize
+		}
+	}
+	for i := plusCodePairLength; i < len(digits); i++ {
+		latSize /= plusCodeGridRows
+		lonSize /= plusCodeGridColumns
+		idx := strings.IndexByte(plusCodeAlphabet, digits[i])
+		south += float64(idx/plusCodeGridColumns) * latSize
+		west += float64(idx%plusCodeGridColumns) * lonSize
+	}
+	if south+latSize > plusCodeLatitudeMax {
+		latSize = plusCodeLatitudeMax - south
+	}
+	return south, west, latSize, lonSize, nil
+}
+
+// Integer precision of the final digit of a 15 digit code, used to encode
+// without accumulating floating point error.
+const (
+	plusCodeFinalLatPrecision = 8000 * 5 * 5 * 5 * 5 * 5
+	plusCodeFinalLonPrecision = 8000 * 4 * 4 * 4 * 4 * 4
+	plusCodeGridDigits        = plusCodeMaxCodeLength - plusCodePairLength
+)
+
+// PlusCode encodes c as a full Plus Code with length digits, not counting
+// the separator. 10 digits identify a roughly 14 m square and 11 a 3 m one;
+// lengths below 8 are padded with zeros.
+func (c *Coordinates) PlusCode(length int) string {
+	if length < 2 {
+		length = 2
+	}
+	if length < plusCodePairLength && length%2 == 1 {
+		length++
+	}
+	if length > plusCodeMaxCodeLength {
+		length = plusCodeMaxCodeLength
+	}
+
+	latVal := int64(math.Floor(math.Round((c.Latitude+plusCodeLatitudeMax)*plusCodeFinalLatPrecision*1e6) / 1e6))
+	lonVal := int64(math.Floor(math.Round((c.Longitude+plusCodeLongitudeMax)*plusCodeFinalLonPrecision*1e6) / 1e6))
+	maxLat := int64(2 * plusCodeLatitudeMax * plusCodeFinalLatPrecision)
+	maxLon := int64(2 * plusCodeLongitudeMax * plusCodeFinalLonPrecision)
+	if latVal < 0 {
+		latVal = 0
+	} else if latVal >= maxLat {
+		latVal = maxLat - 1
+	}
+	lonVal = ((lonVal % maxLon) + maxLon) % maxLon
+
+	code := make([]byte, plusCodeMaxCodeLength)
+	for i := plusCodeGridDigits - 1; i >= 0; i-- {
+		code[plusCodePairLength+i] = plusCodeAlphabet[(latVal%plusCodeGridRows)*plusCodeGridColumns+lonVal%plusCodeGridColumns]
+		latVal /= plusCodeGridRows
+		lonVal /= plusCodeGridColumns
+	}
+	for i := plusCodePairLength/2 - 1; i >= 0; i-- {
+		code[2*i] = plusCodeAlphabet[latVal%int64(plusCodeEncodingBase)]
+		code[2*i+1] = plusCodeAlphabet[lonVal%int64(plusCodeEncodingBase)]
+		latVal /= int64(plusCodeEncodingBase)
+		lonVal /= int64(plusCodeEncodingBase)
+	}
+
+	digits := string(code[:length])
+	if length < plusCodeSeparatorPos {
+		digits += strings.Repeat("0", plusCodeSeparatorPos-length)
+	}
+	return digits[:plusCodeSeparatorPos] + string(plusCodeSeparator) + digits[plusCodeSeparatorPos:]
+}
+
+// RecoverPlusCode expands a short Plus Code to the full code nearest to
+// reference, following the recovery algorithm in the specification.
+func RecoverPlusCode(short string, reference *Coordinates) (string, error) {
+	short = strings.ToUpper(short)
+	if IsPlusCode(short) {
+		return short, nil
+	}
+	if !IsShortPlusCode(short) {
+		return "", errors.Errorf("'%s' is not a short Plus Code", short)
+	}
+
+	padding := plusCodeSeparatorPos - strings.IndexByte(short, plusCodeSeparator)
+	resolution := math.Pow(plusCodeEncodingBase, 2-float64(padding)/2)
+	half := resolution / 2
+	rounded := &Coordinates{
+		Latitude:  math.Floor(reference.Latitude/resolution) * resolution,
+		Longitude: math.Floor(reference.Longitude/resolution) * resolution,
+	}
+	full := rounded.PlusCode(plusCodePairLength)[:padding] + short
+	south, west, latSize, lonSize, err := decodePlusCode(full)
+	if err != nil {
+		return "", err
+	}
+	center := &Coordinates{Latitude: south + latSize/2, Longitude: west + lonSize/2}
+
+	if reference.Latitude+half < center.Latitude && center.Latitude-resolution >= -plusCodeLatitudeMax {
+		center.Latitude -= resolution
+	} else if reference.Latitude-half > center.Latitude && center.Latitude+resolution <= plusCodeLatitudeMax {
+		center.Latitude += resolution
+	}
+	if reference.Longitude+half < center.Longitude {
+		center.Longitude -= resolution
+	} else if reference.Longitude-half > center.Longitude {
+		center.Longitude += resolution
+	}
+	return center.PlusCode(len(full) - 1), nil
+}
@@ -0,0 +1,92 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultUserAgent identifies this library to services whose usage policy
+// requires a descriptive User-Agent, such as Nominatim and Photon.
+const DefaultUserAgent = "github.com/gigawhitlocks/weather geocoding"
+
+// RequestInterval is the minimum time between two requests sent to the same
+// host by the key-free geocoders. The public Nominatim instance allows at
+// most one request per second.
+var RequestInterval = time.Second
+
+type throttle struct {
+	sync.Mutex
+	last time.Time
+}
+
+// wait blocks until RequestInterval has passed since the previous request
+// to the same host.
+func (t *throttle) wait() {
+	t.Lock()
+	defer t.Unlock()
+	if d := RequestInterval - time.Since(t.last); d > 0 {
+		time.Sleep(d)
+	}
+	t.last = time.Now()
+}
+
+var throttles = struct {
+	sync.Mutex
+	hosts map[string]*throttle
+}{hosts: map[string]*throttle{}}
+
+func throttleFor(host string) *throttle {
+	throttles.Lock()
+	defer throttles.Unlock()
+	t, ok := throttles.hosts[host]
+	if !ok {
+		t = new(throttle)
+		throttles.hosts[host] = t
+	}
+	return t
+}
+
+// getThrottledJSON fetches rawurl with the given User-Agent, waiting for its
+// host's turn first, and decodes the JSON response into v.
+func getThrottledJSON(client *http.Client, rawurl, userAgent string, v interface{}) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return errors.Wrapf(err, "invalid URL %s", rawurl)
+	}
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	throttleFor(u.Host).wait()
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch %s", u.Host)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("got status code %d from %s", resp.StatusCode, u.Host)
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal JSON from response body")
+	}
+	return nil
+}
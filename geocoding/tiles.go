@@ -0,0 +1,225 @@
+package geocoding
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// TileSize is the width and height in pixels of a standard slippy map tile.
+const TileSize = 256
+
+// MaxMercatorLatitude is the latitude at which the Web Mercator projection
+// is cut off, making the world square.
+const MaxMercatorLatitude = 85.0511287798066
+
+// tileCount is the number of tiles across the world at zoom.
+func tileCount(zoom int) int {
+	return 1 << uint(zoom)
+}
+
+// wrapTileX wraps an X tile number around the antimeridian.
+func wrapTileX(x, zoom int) int {
+	n := tileCount(zoom)
+	return ((x % n) + n) % n
+}
+
+// NewSlippyMapTile returns tile x, y at zoom with Lat and Long set to its
+// northwest corner. X wraps around the antimeridian.
+func NewSlippyMapTile(x, y, zoom int) *SlippyMapTile {
+	t := &SlippyMapTile{X: wrapTileX(x, zoom), Y: y, Z: zoom}
+	t.Lat, t.Long = t.Num2deg(t)
+	return t
+}
+
+// Valid reports whether the tile exists at its zoom level.
+func (m *SlippyMapTile) Valid() bool {
+	n := tileCount(m.Z)
+	return m.Z >= 0 && m.X >= 0 && m.X < n && m.Y >= 0 && m.Y < n
+}
+
+// Neighbor returns the tile dx tiles east and dy tiles south of m, wrapping
+// around the antimeridian, or nil past the top or bottom of the map.
+func (m *SlippyMapTile) Neighbor(dx, dy int) *SlippyMapTile {
+	y := m.Y + dy
+	if y < 0 || y >= tileCount(m.Z) {
+		return nil
+	}
+	return NewSlippyMapTile(m.X+dx, y, m.Z)
+}
+
+// Bounds returns the area the tile covers.
+func (m *SlippyMapTile) Bounds() *BoundingBox {
+	north, west := m.Num2deg(&SlippyMapTile{X: m.X, Y: m.Y, Z: m.Z})
+	south, east := m.Num2deg(&SlippyMapTile{X: m.X + 1, Y: m.Y + 1, Z: m.Z})
+	return &BoundingBox{South: south, West: west, North: north, East: east}
+}
+
+// TMSY returns the tile's row in the TMS scheme, which counts from the
+// bottom of the map rather than the top. The conversion is its own inverse.
+func (m *SlippyMapTile) TMSY() int {
+	return tileCount(m.Z) - 1 - m.Y
+}
+
+// Quadkey returns the tile's Bing Maps quadkey.
+func (m *SlippyMapTile) Quadkey() string {
+	key := make([]byte, m.Z)
+	for i := m.Z; i > 0; i-- {
+		digit := byte('0')
+		mask := 1 << uint(i-1)
+		if m.X&mask != 0 {
+			digit++
+		}
+		if m.Y&mask != 0 {
+			digit += 2
+		}
+		key[m.Z-i] = digit
+	}
+	return string(key)
+}
+
+// TileFromQuadkey parses a Bing Maps quadkey.
+func TileFromQuadkey(quadkey string) (*SlippyMapTile, error) {
+	x, y := 0, 0
+	for i, r := range quadkey {
+		x, y = x<<1, y<<1
+		switch r {
+		case '0':
+		case '1':
+			x |= 1
+		case '2':
+			y |= 1
+		case '3':
+			x |= 1
+			y |= 1
+		default:
+			return nil, errors.Errorf("invalid quadkey digit '%c' at position %d", r, i)
+		}
+	}
+	return NewSlippyMapTile(x, y, len(quadkey)), nil
+}
+
+// CoordinatesToPixel projects coordinates to global pixel coordinates at
+// zoom, where the whole world is tileSize << zoom pixels square.
+// Latitudes beyond the edge of the Mercator projection are clamped.
+func CoordinatesToPixel(coordinates *Coordinates, zoom, tileSize int) (x, y float64) {
+	lat := math.Max(-MaxMercatorLatitude, math.Min(MaxMercatorLatitude, coordinates.Latitude))
+	t := &SlippyMapTile{Lat: lat, Long: coordinates.Longitude, Z: zoom}
+	x, y = t.Deg2num(t)
+	// rounding at the clamped edges can land a hair outside the map
+	y = math.Max(0, math.Min(float64(tileCount(zoom)), y))
+	return x * float64(tileSize), y * float64(tileSize)
+}
+
+// PixelToCoordinates is the inverse of CoordinatesToPixel. Longitudes are
+// wrapped into [-180, 180).
+func PixelToCoordinates(x, y float64, zoom, tileSize int) *Coordinates {
+	n := float64(tileSize * tileCount(zoom))
+	lon := x/n*360 - 180
+	lat := degrees(math.Atan(math.Sinh(math.Pi * (1 - 2*y/n))))
+	return &Coordinates{Latitude: lat, Longitude: normalizeLongitude(lon)}
+}
+
+// TileRange is a rectangle of tiles at one zoom level. MaxX may be larger
+// than the number of tiles across the world when the range crosses the
+// antimeridian; Tiles wraps those back around.
+type TileRange struct {
+	Z                      int
+	MinX, MinY, MaxX, MaxY int
+}
+
+// Columns returns the width of the range in tiles.
+func (r *TileRange) Columns() int {
+	return r.MaxX - r.MinX + 1
+}
+
+// Rows returns the height of the range in tiles.
+func (r *TileRange) Rows() int {
+	return r.MaxY - r.MinY + 1
+}
+
+// Tiles lists the tiles in the range row by row from the northwest corner.
+func (r *TileRange) Tiles() []*SlippyMapTile {
+	tiles := make([]*SlippyMapTile, 0, r.Columns()*r.Rows())
+	for y := r.MinY; y <= r.MaxY; y++ {
+		for x := r.MinX; x <= r.MaxX; x++ {
+			tiles = append(tiles, NewSlippyMapTile(x, y, r.Z))
+		}
+	}
+	return tiles
+}
+
+// Bounds returns the area covered by the range.
+func (r *TileRange) Bounds() *BoundingBox {
+	nw := NewSlippyMapTile(r.MinX, r.MinY, r.Z).Bounds()
+	se := NewSlippyMapTile(r.MaxX, r.MaxY, r.Z).Bounds()
+	east := se.East
+	if r.Columns() >= tileCount(r.Z) {
+		return &BoundingBox{South: se.South, West: -180, North: nw.North, East: 180}
+	}
+	if east == -180 {
+		east = 180
+	}
+	return &BoundingBox{South: se.South, West: nw.West, North: nw.North, East: east}
+}
+
+// TileRangeForBoundingBox returns the tiles at zoom covering box, including
+// boxes that cross the antimeridian.
+func TileRangeForBoundingBox(box *BoundingBox, zoom int) *TileRange {
+	n := tileCount(zoom)
+	minX, minY := CoordinatesToPixel(&Coordinates{Latitude: box.North, Longitude: box.West}, zoom, 1)
+	maxX, maxY := CoordinatesToPixel(&Coordinates{Latitude: box.South, Longitude: box.East}, zoom, 1)
+	r := &TileRange{
+		Z:    zoom,
+		MinX: clampTile(int(math.Floor(minX)), n),
+		MinY: clampTile(int(math.Floor(minY)), n),
+		MaxX: clampTile(int(math.Ceil(maxX))-1, n),
+		MaxY: clampTile(int(math.Ceil(maxY))-1, n),
+	}
+	if box.CrossesAntimeridian() {
+		r.MaxX += n
+	}
+	return r
+}
+
+// TileGrid returns the columns by rows block of tiles at zoom whose center
+// is as close as possible to coordinates. The grid wraps around the
+// antimeridian and is shifted to stay on the map near the poles.
+func TileGrid(coordinates *Coordinates, zoom, columns, rows int) *TileRange {
+	n := tileCount(zoom)
+	x, y := CoordinatesToPixel(coordinates, zoom, 1)
+	minX := int(math.Floor(x - float64(columns)/2 + 0.5))
+	minY := int(math.Floor(y - float64(rows)/2 + 0.5))
+	if rows >= n {
+		minY, rows = 0, n
+	} else if minY < 0 {
+		minY = 0
+	} else if minY+rows > n {
+		minY = n - rows
+	}
+	wrapped := wrapTileX(minX, zoom)
+	return &TileRange{
+		Z:    zoom,
+		MinX: wrapped,
+		MinY: minY,
+		MaxX: wrapped + columns - 1,
+		MaxY: minY + rows - 1,
+	}
+}
+
+func clampTile(v, n int) int {
+	if v < 0 {
+		return 0
+	}
+	if v >= n {
+		return n - 1
+	}
+	return v
+}
+
+// String formats the tile as z/x/y, the path OpenStreetMap style tile
+// servers use.
+func (m *SlippyMapTile) String() string {
+	return fmt.Sprintf("%d/%d/%d", m.Z, m.X, m.Y)
+}
@@ -0,0 +1,92 @@
+package geocoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordsToSlippyMapTiles(t *testing.T) {
+	tiles := CoordsToSlippyMapTiles(austin, 7)
+	// Austin is in the bottom left quadrant of tile 29/52
+	assert.Equal(t, TopRight, CoordinatesToTile(austin, 7).Corner())
+	assert.Equal(t, "7/28/52", tiles[0].String())
+	assert.Equal(t, "7/29/52", tiles[1].String())
+	assert.Equal(t, "7/28/53", tiles[2].String())
+	assert.Equal(t, "7/29/53", tiles[3].String())
+	assert.Equal(t, austin.Latitude, tiles[1].Lat)
+	for _, tile := range tiles {
+		assert.NotZero(t, tile.Lat)
+		assert.NotZero(t, tile.Long)
+	}
+	assert.True(t, tiles[1].Bounds().Contains(austin))
+
+	// near the antimeridian the block wraps rather than leaving the map
+	tiles = CoordsToSlippyMapTiles(&Coordinates{Latitude: 0.1, Longitude: 179.9}, 2)
+	assert.Equal(t, "2/3/1", tiles[0].String())
+	assert.Equal(t, "2/0/1", tiles[1].String())
+}
+
+func TestPixelConversion(t *testing.T) {
+	x, y := CoordinatesToPixel(&Coordinates{}, 0, TileSize)
+	assert.Equal(t, 128.0, x)
+	assert.InDelta(t, 128.0, y, 1e-9)
+
+	x, y = CoordinatesToPixel(austin, 10, 512)
+	assertNear(t, austin, PixelToCoordinates(x, y, 10, 512), 1e-9)
+
+	_, y = CoordinatesToPixel(&Coordinates{Latitude: 89}, 3, TileSize)
+	assert.Equal(t, 0.0, y)
+	assert.Equal(t, -180.0, PixelToCoordinates(TileSize*4, 0, 2, TileSize).Longitude)
+}
+
+func TestTileBoundsAndSchemes(t *testing.T) {
+	tile := NewSlippyMapTile(0, 0, 1)
+	b := tile.Bounds()
+	assert.Equal(t, -180.0, b.West)
+	assert.Equal(t, 0.0, b.East)
+	assert.InDelta(t, MaxMercatorLatitude, b.North, 1e-9)
+	assert.InDelta(t, 0, b.South, 1e-9)
+	assert.Equal(t, 1, tile.TMSY())
+
+	tile = NewSlippyMapTile(3, 5, 3)
+	assert.Equal(t, "213", tile.Quadkey())
+	parsed, err := TileFromQuadkey("213")
+	require.NoError(t, err)
+	assert.Equal(t, tile, parsed)
+	_, err = TileFromQuadkey("214")
+	assert.Error(t, err)
+
+	assert.Equal(t, 0, NewSlippyMapTile(8, 0, 3).X)
+	assert.Equal(t, 7, NewSlippyMapTile(-1, 0, 3).X)
+	assert.Nil(t, tile.Neighbor(0, 3))
+	assert.Equal(t, "3/2/6", tile.Neighbor(-1, 1).String())
+	assert.False(t, (&SlippyMapTile{X: 8, Y: 0, Z: 3}).Valid())
+}
+
+func TestTileRanges(t *testing.T) {
+	r := TileRangeForBoundingBox(austin.BoundingBox(150000), 7)
+	assert.Equal(t, &TileRange{Z: 7, MinX: 28, MinY: 52, MaxX: 29, MaxY: 53}, r)
+	assert.Len(t, r.Tiles(), 4)
+	assert.True(t, r.Bounds().Contains(austin.Destination(45, 150000)))
+
+	r = TileRangeForBoundingBox(&BoundingBox{South: -10, West: 170, North: 10, East: -170}, 3)
+	assert.Equal(t, 2, r.Columns())
+	tiles := r.Tiles()
+	assert.Equal(t, 7, tiles[0].X)
+	assert.Equal(t, 0, tiles[1].X)
+	assert.True(t, r.Bounds().CrossesAntimeridian())
+
+	r = TileGrid(austin, 7, 3, 3)
+	assert.Equal(t, &TileRange{Z: 7, MinX: 28, MinY: 51, MaxX: 30, MaxY: 53}, r)
+	assert.True(t, r.Tiles()[4].Bounds().Contains(austin))
+
+	r = TileGrid(&Coordinates{Latitude: 85}, 2, 2, 3)
+	assert.Equal(t, 0, r.MinY)
+	assert.Equal(t, 2, r.MaxY)
+
+	r = TileGrid(&Coordinates{}, 1, 4, 4)
+	assert.Equal(t, 2, r.Rows())
+	assert.Equal(t, &BoundingBox{South: r.Bounds().South, West: -180, North: r.Bounds().North, East: 180}, r.Bounds())
+}
@@ -0,0 +1,49 @@
+package geocoding
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zsefvlol/timezonemapper"
+)
+
+// TimeZoneName returns the IANA time zone at c from an embedded map of time
+// zone boundaries, so no lookup service is needed. Points the map doesn't
+// cover fall back to the nautical zone for their longitude, e.g. Etc/GMT+6.
+func (c *Coordinates) TimeZoneName() string {
+	if name := timezonemapper.LatLngToTimezoneString(c.Latitude, c.Longitude); name != "" {
+		return name
+	}
+	offset := int(math.Round(c.Longitude / 15))
+	switch {
+	case offset == 0:
+		return "Etc/GMT"
+	case offset > 0:
+		// the Etc zones use POSIX signs, which are inverted
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	default:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+}
+
+// TimeZone loads the time zone at c from the system time zone database.
+func (c *Coordinates) TimeZone() (*time.Location, error) {
+	name := c.TimeZoneName()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone %s", name)
+	}
+	return loc, nil
+}
+
+// LocalTime converts t to the time zone at c, leaving it unchanged if the
+// zone can't be loaded.
+func (c *Coordinates) LocalTime(t time.Time) time.Time {
+	loc, err := c.TimeZone()
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
@@ -0,0 +1,26 @@
+package geocoding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeZoneName(t *testing.T) {
+	assert.Equal(t, "America/Chicago", austin.TimeZoneName())
+	assert.Equal(t, "Europe/London", (&Coordinates{Latitude: 51.5074, Longitude: -0.1278}).TimeZoneName())
+	assert.Equal(t, "Asia/Tokyo", (&Coordinates{Latitude: 35.6762, Longitude: 139.6503}).TimeZoneName())
+	assert.Equal(t, "America/Phoenix", (&Coordinates{Latitude: 33.4484, Longitude: -112.0740}).TimeZoneName())
+}
+
+func TestLocalTime(t *testing.T) {
+	_, err := austin.TimeZone()
+	require.NoError(t, err)
+
+	utc := time.Date(2020, 7, 4, 17, 0, 0, 0, time.UTC)
+	local := austin.LocalTime(utc)
+	assert.Equal(t, "12:00 PM CDT", local.Format("3:04 PM MST"))
+	assert.True(t, utc.Equal(local))
+}
@@ -4,6 +4,9 @@ go 1.14
 
 require (
 	github.com/disintegration/imaging v1.6.2
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.6.1
+	github.com/zsefvlol/timezonemapper v1.0.0
+	golang.org/x/image v0.0.0-20191009234506-e7c1f5e7dbb8
 )
@@ -0,0 +1,285 @@
+// Package location turns whatever a user typed as a location into
+// coordinates, picking the right decoder or lookup service for the format.
+package location
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/nws"
+	"github.com/pkg/errors"
+)
+
+type Kind int
+
+const (
+	FreeText Kind = iota
+	Zip
+	Zip4
+	LatLong
+	DMS
+	Maidenhead
+	MGRS
+	Geohash
+	PlusCode
+	ICAO
+	Station
+)
+
+var kindNames = map[Kind]string{
+	FreeText:   "free text",
+	Zip:        "ZIP code",
+	Zip4:       "ZIP+4 code",
+	LatLong:    "latitude/longitude",
+	DMS:        "degrees/minutes/seconds",
+	Maidenhead: "Maidenhead locator",
+	MGRS:       "MGRS grid reference",
+	Geohash:    "geohash",
+	PlusCode:   "Plus Code",
+	ICAO:       "ICAO airport code",
+	Station:    "NWS station ID",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// prefixes let a user force a kind when the input is ambiguous, as in
+// "geohash:dr5ru" or "station:KATT".
+var prefixes = map[string]Kind{
+	"zip":        Zip,
+	"geo":        LatLong,
+	"grid":       Maidenhead,
+	"maidenhead": Maidenhead,
+	"mgrs":       MGRS,
+	"geohash":    Geohash,
+	"pluscode":   PlusCode,
+	"icao":       ICAO,
+	"station":    Station,
+	"place":      FreeText,
+}
+
+var (
+	zipPattern      = regexp.MustCompile(`^\d{5}$`)
+	zip4Pattern     = regexp.MustCompile(`^\d{5}-\d{4}$`)
+	latLongPattern  = regexp.MustCompile(`^([-+]?\d{1,3}(?:\.\d+)?)\s*[,; ]\s*([-+]?\d{1,3}(?:\.\d+)?)$`)
+	icaoPattern     = regexp.MustCompile(`^[A-Z]{4}$`)
+	stationPattern  = regexp.MustCompile(`^[A-Z][A-Z0-9]{2,4}$`)
+	geohashPattern  = regexp.MustCompile(`^[0-9b-hjkmnp-z]{5,12}$`)
+	hasDigitPattern = regexp.MustCompile(`[0-9]`)
+	hasAlphaPattern = regexp.MustCompile(`[a-zA-Z]`)
+)
+
+// Query is a classified location query.
+type Query struct {
+	Kind Kind
+	// Text is the query with any kind prefix and surrounding space removed.
+	Text string
+}
+
+// Parse classifies a location query. Anything it doesn't recognize is
+// FreeText, to be handed to a geocoder.
+func Parse(input string) *Query {
+	text := strings.TrimSpace(input)
+	if i := strings.Index(text, ":"); i > 0 {
+		if kind, ok := prefixes[strings.ToLower(text[:i])]; ok {
+			return &Query{Kind: kind, Text: strings.TrimSpace(text[i+1:])}
+		}
+	}
+	return &Query{Kind: classify(text), Text: text}
+}
+
+func classify(text string) Kind {
+	switch {
+	case zipPattern.MatchString(text):
+		return Zip
+	case zip4Pattern.MatchString(text):
+		return Zip4
+	case latLongPattern.MatchString(text):
+		return LatLong
+	case geo.IsDMS(text):
+		return DMS
+	case geo.IsPlusCode(text), isShortPlusCodeWithLocality(text):
+		return PlusCode
+	case geo.IsMGRS(text):
+		return MGRS
+	case geo.IsMaidenhead(text) && len(text) >= 4:
+		return Maidenhead
+	case icaoPattern.MatchString(text):
+		return ICAO
+	case stationPattern.MatchString(text) && hasDigitPattern.MatchString(text):
+		return Station
+	case geohashPattern.MatchString(text) &&
+		hasDigitPattern.MatchString(text) && hasAlphaPattern.MatchString(text):
+		return Geohash
+	}
+	return FreeText
+}
+
+// Location is a resolved query.
+type Location struct {
+	Query       *Query
+	Coordinates geo.Coordinates
+	// Name is a human readable name for the location when the backend
+	// that resolved it provides one.
+	Name string
+}
+
+// Resolver resolves queries to coordinates. Formats that encode
+// coordinates are decoded locally; ZIP codes come from the bundled NWS ZIP
+// table, ICAO and station IDs from the NWS stations API, and everything
+// else from Geocoder.
+type Resolver struct {
+	Geocoder geo.Geocoder
+
+	// LookupZip and LookupStation default to the nws package.
+	LookupZip     func(zip string) (*geo.Coordinates, error)
+	LookupStation func(id string) (*geo.Coordinates, string, error)
+}
+
+func NewResolver(geocoder geo.Geocoder) *Resolver {
+	return &Resolver{Geocoder: geocoder}
+}
+
+// Resolve parses input and resolves it to coordinates.
+func (r *Resolver) Resolve(input string) (*Location, error) {
+	q := Parse(input)
+	l, err := r.ResolveQuery(q)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve '%s' as %s", input, q.Kind)
+	}
+	return l, nil
+}
+
+func (r *Resolver) ResolveQuery(q *Query) (*Location, error) {
+	var (
+		c    *geo.Coordinates
+		name string
+		err  error
+	)
+	switch q.Kind {
+	case Zip, Zip4:
+		c, err = r.lookupZip(q.Text[:min(5, len(q.Text))])
+		name = q.Text
+	case LatLong:
+		c, err = parseLatLong(q.Text)
+	case DMS:
+		c, err = geo.ParseDMS(q.Text)
+	case Maidenhead:
+		c, err = geo.ParseMaidenhead(q.Text)
+	case MGRS:
+		c, err = geo.ParseMGRS(q.Text)
+	case Geohash:
+		c, err = geo.ParseGeohash(q.Text)
+	case PlusCode:
+		c, name, err = r.resolvePlusCode(q.Text)
+	case ICAO:
+		c, name, err = r.lookupStation(strings.ToUpper(q.Text))
+		if err != nil && r.Geocoder != nil {
+			// not every airport is an NWS observation station
+			c, name, err = r.geocode(fmt.Sprintf("%s airport", strings.ToUpper(q.Text)))
+		}
+		if err != nil && r.Geocoder != nil {
+			// four capital letters may just be a place, like OHIO or ROME
+			c, name, err = r.geocode(q.Text)
+		}
+	case Station:
+		c, name, err = r.lookupStation(strings.ToUpper(q.Text))
+	default:
+		c, name, err = r.geocode(q.Text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Location{Query: q, Coordinates: *c, Name: name}, nil
+}
+
+func (r *Resolver) lookupZip(zip string) (*geo.Coordinates, error) {
+	if r.LookupZip != nil {
+		return r.LookupZip(zip)
+	}
+	l, err := nws.LookupZip(zip)
+	if err != nil {
+		return nil, err
+	}
+	return &geo.Coordinates{Latitude: l[0], Longitude: l[1]}, nil
+}
+
+func (r *Resolver) lookupStation(id string) (*geo.Coordinates, string, error) {
+	if r.LookupStation != nil {
+		return r.LookupStation(id)
+	}
+	s, err := nws.GetStation(id)
+	if err != nil {
+		return nil, "", err
+	}
+	l, err := s.LatLong()
+	if err != nil {
+		return nil, "", err
+	}
+	return &geo.Coordinates{Latitude: l[0], Longitude: l[1]}, s.Name, nil
+}
+
+func (r *Resolver) geocode(text string) (*geo.Coordinates, string, error) {
+	if r.Geocoder == nil {
+		return nil, "", errors.New("no geocoder configured for free text locations")
+	}
+	if err := r.Geocoder.Geocode(text); err != nil {
+		return nil, "", err
+	}
+	return r.Geocoder.Latlong(), r.Geocoder.ParsedLocation(), nil
+}
+
+// isShortPlusCodeWithLocality matches a short Plus Code followed by the
+// place it is relative to, the way Google Maps shares them: "9G8F+6X Zurich".
+func isShortPlusCodeWithLocality(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) > 1 && geo.IsShortPlusCode(strings.TrimRight(fields[0], ","))
+}
+
+func (r *Resolver) resolvePlusCode(text string) (*geo.Coordinates, string, error) {
+	if geo.IsPlusCode(text) {
+		c, err := geo.ParsePlusCode(text)
+		return c, "", err
+	}
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return nil, "", errors.Errorf("'%s' is a short Plus Code without a locality", text)
+	}
+	reference, name, err := r.geocode(strings.Join(fields[1:], " "))
+	if err != nil {
+		return nil, "", err
+	}
+	code, err := geo.RecoverPlusCode(strings.TrimRight(fields[0], ","), reference)
+	if err != nil {
+		return nil, "", err
+	}
+	c, err := geo.ParsePlusCode(code)
+	return c, name, err
+}
+
+func parseLatLong(text string) (*geo.Coordinates, error) {
+	m := latLongPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, errors.Errorf("'%s' is not a latitude,longitude pair", text)
+	}
+	lat, _ := strconv.ParseFloat(m[1], 64)
+	lon, _ := strconv.ParseFloat(m[2], 64)
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, errors.Errorf("'%s' is out of range", text)
+	}
+	return &geo.Coordinates{Latitude: lat, Longitude: lon}, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,131 @@
+package location
+
+import (
+	"strings"
+	"testing"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for input, kind := range map[string]Kind{
+		"78703":                     Zip,
+		"78703-1234":                Zip4,
+		"30.2711, -97.7437":         LatLong,
+		"30.2711 -97.7437":          LatLong,
+		`30°16'16"N 97°44'37"W`:     DMS,
+		"30.27N 97.74W":             DMS,
+		"EM10dg":                    Maidenhead,
+		"EM10":                      Maidenhead,
+		"14RPU2078650551":           MGRS,
+		"14R PU 20786 50551":        MGRS,
+		"9v6kpmr1":                  Geohash,
+		"862483V3+8C":               PlusCode,
+		"KAUS":                      ICAO,
+		"EGLL":                      ICAO,
+		"KT41":                      Station,
+		"CWTS2":                     Station,
+		"Austin, TX":                FreeText,
+		"austin":                    FreeText,
+		"denver":                    FreeText,
+		"Rome":                      FreeText,
+		"geohash:denver":            Geohash,
+		"station:katt":              Station,
+		"place:EM10":                FreeText,
+		"1600 Pennsylvania Ave, DC": FreeText,
+		"  78703  ":                 Zip,
+		"Washington: the state":     FreeText,
+		"geo:30.2711,-97.7437":      LatLong,
+		"Saint-Jean-de-Luz, France": FreeText,
+	} {
+		assert.Equal(t, kind, Parse(input).Kind, input)
+	}
+	assert.Equal(t, "denver", Parse("geohash: denver").Text)
+}
+
+type fakeGeocoder struct {
+	location string
+}
+
+// airports are the only airports fakeGeocoder knows. Like real geocoders,
+// it finds something for anything else, including bare ICAO codes.
+var airports = map[string]bool{"EGLL": true}
+
+func (f *fakeGeocoder) Geocode(location string) error {
+	if location == "nowhere" {
+		return errors.New("no results")
+	}
+	if code := strings.TrimSuffix(location, " airport"); code != location && !airports[code] {
+		return errors.New("no results")
+	}
+	f.location = location
+	return nil
+}
+
+func (f *fakeGeocoder) Latlong() *geo.Coordinates {
+	if f.location == "Zurich" {
+		return &geo.Coordinates{Latitude: 47.37, Longitude: 8.54}
+	}
+	return &geo.Coordinates{Latitude: 1, Longitude: 2}
+}
+
+func (f *fakeGeocoder) ParsedLocation() string {
+	return f.location
+}
+
+func TestResolve(t *testing.T) {
+	r := NewResolver(&fakeGeocoder{})
+	r.LookupZip = func(zip string) (*geo.Coordinates, error) {
+		assert.Equal(t, "78703", zip)
+		return &geo.Coordinates{Latitude: 30.29, Longitude: -97.77}, nil
+	}
+	r.LookupStation = func(id string) (*geo.Coordinates, string, error) {
+		if id != "KATT" {
+			return nil, "", errors.New("not found")
+		}
+		return &geo.Coordinates{Latitude: 30.32, Longitude: -97.77}, "Austin Camp Mabry", nil
+	}
+
+	l, err := r.Resolve("78703-1234")
+	require.NoError(t, err)
+	assert.Equal(t, 30.29, l.Coordinates.Latitude)
+	assert.Equal(t, Zip4, l.Query.Kind)
+
+	l, err = r.Resolve("30.5,-97.5")
+	require.NoError(t, err)
+	assert.Equal(t, geo.Coordinates{Latitude: 30.5, Longitude: -97.5}, l.Coordinates)
+
+	l, err = r.Resolve("station:katt")
+	require.NoError(t, err)
+	assert.Equal(t, "Austin Camp Mabry", l.Name)
+
+	l, err = r.Resolve("EGLL")
+	require.NoError(t, err)
+	assert.Equal(t, "EGLL airport", l.Name, "airports that are not NWS stations fall back to the geocoder")
+
+	l, err = r.Resolve("OHIO")
+	require.NoError(t, err)
+	assert.Equal(t, ICAO, l.Query.Kind)
+	assert.Equal(t, "OHIO", l.Name, "places spelled like ICAO codes are geocoded as they are when no airport matches")
+
+	l, err = r.Resolve("EM10dg")
+	require.NoError(t, err)
+	assert.InDelta(t, 30.27, l.Coordinates.Latitude, 0.01)
+
+	l, err = r.Resolve("Austin, TX")
+	require.NoError(t, err)
+	assert.Equal(t, "Austin, TX", l.Name)
+
+	l, err = r.Resolve("9G8F+6X Zurich")
+	require.NoError(t, err)
+	assert.InDelta(t, 47.3655625, l.Coordinates.Latitude, 1e-6)
+	assert.InDelta(t, 8.5249375, l.Coordinates.Longitude, 1e-6)
+
+	_, err = r.Resolve("nowhere")
+	assert.Error(t, err)
+	_, err = r.Resolve("91.0, 10")
+	assert.Error(t, err)
+}
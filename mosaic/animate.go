@@ -0,0 +1,179 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+)
+
+// TimedSource provides the image for a tile at a moment in time, such as
+// past radar or a future nowcast.
+type TimedSource interface {
+	TileAt(tile *geo.SlippyMapTile, t time.Time) (image.Image, error)
+}
+
+var _ TimedSource = &XYZSource{}
+var _ TimedSource = &WMSSource{}
+
+// At fixes a TimedSource at t so it can be rendered as a TileSource.
+func At(source TimedSource, t time.Time) TileSource {
+	return TileSourceFunc(func(tile *geo.SlippyMapTile) (image.Image, error) {
+		return source.TileAt(tile, t)
+	})
+}
+
+// Timesteps returns the times from start to end inclusive, step apart,
+// with start rounded down to a multiple of step.
+func Timesteps(start, end time.Time, step time.Duration) []time.Time {
+	if step <= 0 {
+		return nil
+	}
+	times := []time.Time{}
+	for t := start.Truncate(step); !t.After(end); t = t.Add(step) {
+		times = append(times, t)
+	}
+	return times
+}
+
+// TimedLayer is a TimedSource drawn over the basemap like a Layer.
+type TimedLayer struct {
+	Source  TimedSource
+	Opacity float64
+	Blend   BlendMode
+}
+
+// Animation is a map whose weather layers change over Times. The basemap is
+// rendered once and shared by every frame.
+type Animation struct {
+	Viewport *Viewport
+	Basemap  TileSource
+	Layers   []*TimedLayer
+	Times    []time.Time
+	// Annotations, if set, are drawn on every frame.
+	Annotations *Annotations
+	// Each frame is labeled with its time in Location, formatted with
+	// TimeFormat. Location defaults to UTC and TimeFormat to
+	// "Mon 3:04 PM MST".
+	Location   *time.Location
+	TimeFormat string
+}
+
+// Frames renders a frame for each of the animation's times.
+func (a *Animation) Frames() ([]*image.NRGBA, error) {
+	if len(a.Times) == 0 {
+		return nil, errors.New("no times to animate")
+	}
+	if a.Basemap == nil {
+		return nil, errors.New("no basemap")
+	}
+	basemap, err := RenderLayer(a.Viewport, a.Basemap)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render basemap")
+	}
+
+	location := a.Location
+	if location == nil {
+		location = time.UTC
+	}
+	format := a.TimeFormat
+	if format == "" {
+		format = "Mon 3:04 PM MST"
+	}
+
+	frames := make([]*image.NRGBA, len(a.Times))
+	for i, t := range a.Times {
+		layers := []*Layer{}
+		for _, layer := range a.Layers {
+			layers = append(layers, &Layer{Source: At(layer.Source, t), Opacity: layer.Opacity, Blend: layer.Blend})
+		}
+		frame, err := a.renderFrame(basemap, layers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render frame for %s", t.Format(time.RFC3339))
+		}
+		if a.Annotations != nil {
+			Annotate(frame, a.Viewport, a.Annotations)
+		}
+		label := t.In(location).Format(format)
+		drawText(frame, image.Pt(frame.Bounds().Max.X-textWidth(label)-annotationMargin,
+			annotationMargin+lineHeight), label, annotationInk, annotationHalo)
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// renderFrame draws layers over a copy of basemap.
+func (a *Animation) renderFrame(basemap *image.NRGBA, layers []*Layer) (*image.NRGBA, error) {
+	frame := imaging.Clone(basemap)
+	if len(layers) == 0 {
+		return frame, nil
+	}
+	overlays := make([]*image.NRGBA, len(layers))
+	errs := make(chan error, len(layers))
+	sem := newFetchLimit()
+	for i, layer := range layers {
+		go func(i int, layer *Layer) {
+			var err error
+			overlays[i], err = renderLayer(a.Viewport, layer.Source, sem)
+			errs <- err
+		}(i, layer)
+	}
+	var err error
+	for range layers {
+		if e := <-errs; e != nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, layer := range layers {
+		composite(frame, overlays[i], layer.Opacity, layer.Blend)
+	}
+	return frame, nil
+}
+
+// AnimationFormat is an animated image format.
+type AnimationFormat string
+
+const (
+	GIF  AnimationFormat = "gif"
+	APNG AnimationFormat = "apng"
+)
+
+// EncodeAnimation writes frames to w in format, showing each for delay and
+// looping forever.
+func EncodeAnimation(w io.Writer, format AnimationFormat, frames []*image.NRGBA, delay time.Duration) error {
+	switch format {
+	case GIF, "":
+		return EncodeGIF(w, frames, delay)
+	case APNG:
+		return EncodeAPNG(w, frames, delay)
+	}
+	return errors.Errorf("unknown animation format '%s'", format)
+}
+
+// EncodeGIF writes frames to w as a looping GIF, dithered to the Plan 9
+// palette.
+func EncodeGIF(w io.Writer, frames []*image.NRGBA, delay time.Duration) error {
+	if len(frames) == 0 {
+		return errors.New("no frames to encode")
+	}
+	o := &gif.GIF{}
+	for _, frame := range frames {
+		im := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		// draw onto white so transparent areas don't dither to black
+		background := imaging.New(frame.Bounds().Dx(), frame.Bounds().Dy(), color.White)
+		draw.FloydSteinberg.Draw(im, im.Bounds(), imaging.Overlay(background, frame, image.Pt(0, 0), 1), image.Point{})
+		o.Image = append(o.Image, im)
+		o.Delay = append(o.Delay, int(delay/(10*time.Millisecond)))
+	}
+	return errors.Wrap(gif.EncodeAll(w, o), "failed to encode GIF")
+}
@@ -0,0 +1,102 @@
+package mosaic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hourlyTiles returns tiles whose red channel is the hour of the time
+// they're requested for.
+type hourlyTiles struct{}
+
+func (hourlyTiles) TileAt(tile *geo.SlippyMapTile, t time.Time) (image.Image, error) {
+	return imaging.New(geo.TileSize, geo.TileSize, color.NRGBA{uint8(t.Hour()), 0, 0, 255}), nil
+}
+
+func TestTimesteps(t *testing.T) {
+	start := time.Date(2020, 8, 1, 12, 7, 0, 0, time.UTC)
+	times := Timesteps(start, start.Add(30*time.Minute), 10*time.Minute)
+	require.Len(t, times, 4)
+	assert.Equal(t, time.Date(2020, 8, 1, 12, 0, 0, 0, time.UTC), times[0])
+	assert.Equal(t, time.Date(2020, 8, 1, 12, 30, 0, 0, time.UTC), times[3])
+	assert.Nil(t, Timesteps(start, start, 0))
+}
+
+func TestAnimationFrames(t *testing.T) {
+	var basemapFetches int32
+	basemap := TileSourceFunc(func(tile *geo.SlippyMapTile) (image.Image, error) {
+		atomic.AddInt32(&basemapFetches, 1)
+		return imaging.New(geo.TileSize, geo.TileSize, color.White), nil
+	})
+	start := time.Date(2020, 8, 1, 1, 0, 0, 0, time.UTC)
+	a := &Animation{
+		Viewport: &Viewport{Zoom: 2, Width: 256, Height: 256},
+		Basemap:  basemap,
+		Layers:   []*TimedLayer{{Source: hourlyTiles{}, Opacity: 1}},
+		Times:    Timesteps(start, start.Add(2*time.Hour), time.Hour),
+	}
+	frames, err := a.Frames()
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	for i, frame := range frames {
+		assert.Equal(t, color.NRGBA{uint8(i + 1), 0, 0, 255}, frame.NRGBAAt(128, 128))
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&basemapFetches), "the basemap is shared by every frame")
+	assert.NotEqual(t, frames[0].Pix, frames[1].Pix)
+
+	a.Times = nil
+	_, err = a.Frames()
+	assert.Error(t, err)
+}
+
+func testFrames() []*image.NRGBA {
+	return []*image.NRGBA{
+		imaging.New(8, 4, color.NRGBA{255, 0, 0, 255}),
+		imaging.New(8, 4, color.NRGBA{0, 0, 255, 128}),
+	}
+}
+
+func TestEncodeGIF(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, EncodeAnimation(buf, GIF, testFrames(), 500*time.Millisecond))
+	g, err := gif.DecodeAll(buf)
+	require.NoError(t, err)
+	assert.Len(t, g.Image, 2)
+	assert.Equal(t, []int{50, 50}, g.Delay)
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, EncodeAnimation(buf, APNG, testFrames(), 500*time.Millisecond))
+
+	// decoders without APNG support show the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 8, 4), img.Bounds())
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, color.NRGBAModel.Convert(img.At(7, 3)))
+
+	chunks := []string{}
+	data := buf.Bytes()[8:]
+	for len(data) > 0 {
+		length := binary.BigEndian.Uint32(data)
+		chunks = append(chunks, string(data[4:8]))
+		data = data[12+length:]
+	}
+	assert.Equal(t, []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "IEND"}, chunks)
+
+	err = EncodeAPNG(buf, append(testFrames(), imaging.New(1, 1, color.White)), time.Second)
+	assert.Error(t, err, "frames must be the same size")
+	assert.Error(t, EncodeAnimation(buf, "webm", testFrames(), time.Second))
+}
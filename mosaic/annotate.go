@@ -0,0 +1,237 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Legend explains the colors of a weather layer, from the first stop to
+// the last.
+type Legend struct {
+	Title string
+	Stops []LegendStop
+}
+
+// LegendStop is a color on a Legend and what it means.
+type LegendStop struct {
+	Label string
+	Color color.Color
+}
+
+// Annotations are drawn over a rendered map by Annotate.
+type Annotations struct {
+	// Marker, when set, is pinned on the map with Label beside it.
+	Marker *geo.Coordinates
+	Label  string
+	// ScaleBar draws scales in kilometers and miles in the bottom left
+	// corner.
+	ScaleBar bool
+	// Legends are stacked in the top left corner.
+	Legends []*Legend
+	// Attribution is printed in the bottom right corner. Most tile licenses
+	// require it; see Attribution.
+	Attribution string
+}
+
+var (
+	annotationFace  font.Face = basicfont.Face7x13
+	annotationInk             = color.NRGBA{0x20, 0x20, 0x20, 0xff}
+	annotationHalo            = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	annotationPanel           = color.NRGBA{0xff, 0xff, 0xff, 0xc0}
+	markerColor               = color.NRGBA{0xd7, 0x26, 0x1e, 0xff}
+)
+
+const (
+	annotationMargin  = 6
+	annotationPadding = 4
+	lineHeight        = 13
+	scaleBarMaxWidth  = 120
+)
+
+// Annotate draws a onto dst, a map rendered for v.
+func Annotate(dst draw.Image, v *Viewport, a *Annotations) {
+	if a.Marker != nil {
+		at := v.Point(a.Marker)
+		drawMarker(dst, at)
+		if a.Label != "" {
+			drawText(dst, image.Pt(at.X+10, at.Y-10), a.Label, annotationInk, annotationHalo)
+		}
+	}
+
+	top := annotationMargin
+	for _, legend := range a.Legends {
+		top = drawLegend(dst, image.Pt(annotationMargin, top), legend).Max.Y + annotationMargin
+	}
+
+	bottom := dst.Bounds().Max.Y
+	if a.Attribution != "" {
+		b := dst.Bounds()
+		width := textWidth(a.Attribution)
+		panel := image.Rect(b.Max.X-width-2*annotationPadding, b.Max.Y-lineHeight-annotationPadding, b.Max.X, b.Max.Y)
+		draw.Draw(dst, panel, image.NewUniform(annotationPanel), image.Point{}, draw.Over)
+		drawText(dst, image.Pt(panel.Min.X+annotationPadding, b.Max.Y-annotationPadding), a.Attribution, annotationInk, nil)
+		bottom = panel.Min.Y
+	}
+
+	if a.ScaleBar {
+		drawScaleBar(dst, v, bottom)
+	}
+}
+
+// Attribution collects the attributions of the layers' sources, in order
+// and without repeats, for Annotations.
+func Attribution(layers ...*Layer) string {
+	seen := map[string]bool{}
+	parts := []string{}
+	for _, layer := range layers {
+		s, ok := layer.Source.(interface{ source() *Source })
+		if !ok {
+			continue
+		}
+		text := s.source().Attribution
+		if text != "" && !seen[text] {
+			seen[text] = true
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// transliterations spell out symbols the annotation font lacks.
+var transliterations = strings.NewReplacer("©", "(c)", "°", "", "µ", "u")
+
+func textWidth(s string) int {
+	return font.MeasureString(annotationFace, transliterations.Replace(s)).Ceil()
+}
+
+// drawText draws s with its baseline starting at at, outlined with halo
+// unless halo is nil.
+func drawText(dst draw.Image, at image.Point, s string, ink color.Color, halo color.Color) {
+	s = transliterations.Replace(s)
+	d := &font.Drawer{Dst: dst, Face: annotationFace}
+	if halo != nil {
+		d.Src = image.NewUniform(halo)
+		for _, off := range []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {1, 1}, {-1, 1}, {1, -1}} {
+			d.Dot = fixed.P(at.X+off.X, at.Y+off.Y)
+			d.DrawString(s)
+		}
+	}
+	d.Src = image.NewUniform(ink)
+	d.Dot = fixed.P(at.X, at.Y)
+	d.DrawString(s)
+}
+
+// drawMarker draws a pin whose tip is at at.
+func drawMarker(dst draw.Image, at image.Point) {
+	const radius = 7.0
+	cx, cy := float64(at.X)+.5, float64(at.Y)-16
+	tip := float64(at.Y) + .5
+	inside := func(px, py, r float64) bool {
+		if math.Hypot(px-cx, py-cy) <= r {
+			return true
+		}
+		// the point tapers from the circle's widest point to the tip
+		return py >= cy && py <= tip && math.Abs(px-cx) <= r*(tip-py)/(tip-cy)
+	}
+	for y := at.Y - 25; y <= at.Y; y++ {
+		for x := at.X - 9; x <= at.X+9; x++ {
+			px, py := float64(x)+.5, float64(y)+.5
+			switch {
+			case math.Hypot(px-cx, py-cy) <= 2.5:
+				dst.Set(x, y, annotationHalo)
+			case inside(px, py, radius-1.5):
+				dst.Set(x, y, markerColor)
+			case inside(px, py, radius):
+				dst.Set(x, y, annotationHalo)
+			}
+		}
+	}
+}
+
+// drawLegend draws legend with its top left corner at at, returning the
+// area it covers.
+func drawLegend(dst draw.Image, at image.Point, legend *Legend) image.Rectangle {
+	const swatch = 12
+	width := textWidth(legend.Title)
+	for _, stop := range legend.Stops {
+		if w := swatch + annotationPadding + textWidth(stop.Label); w > width {
+			width = w
+		}
+	}
+	rows := len(legend.Stops)
+	if legend.Title != "" {
+		rows++
+	}
+	panel := image.Rect(at.X, at.Y,
+		at.X+width+2*annotationPadding, at.Y+rows*lineHeight+2*annotationPadding)
+	draw.Draw(dst, panel, image.NewUniform(annotationPanel), image.Point{}, draw.Over)
+
+	x, y := at.X+annotationPadding, at.Y+annotationPadding
+	if legend.Title != "" {
+		drawText(dst, image.Pt(x, y+lineHeight-3), legend.Title, annotationInk, nil)
+		y += lineHeight
+	}
+	for _, stop := range legend.Stops {
+		box := image.Rect(x, y+1, x+swatch, y+lineHeight-1)
+		draw.Draw(dst, box, image.NewUniform(annotationInk), image.Point{}, draw.Src)
+		draw.Draw(dst, box.Inset(1), image.NewUniform(stop.Color), image.Point{}, draw.Src)
+		drawText(dst, image.Pt(x+swatch+annotationPadding, y+lineHeight-3), stop.Label, annotationInk, nil)
+		y += lineHeight
+	}
+	return panel
+}
+
+// metersPerPixel is the map scale at the viewport's center.
+func (v *Viewport) metersPerPixel() float64 {
+	world := float64(v.tileSize() << uint(v.Zoom))
+	return 2 * webMercatorExtent * math.Cos(v.Center.Latitude*math.Pi/180) / world
+}
+
+// niceDistance returns the largest 1, 2 or 5 times a power of ten that is
+// no more than max.
+func niceDistance(max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(max)))
+	for _, step := range []float64{5, 2, 1} {
+		if step*magnitude <= max {
+			return step * magnitude
+		}
+	}
+	return magnitude
+}
+
+// drawScaleBar draws kilometer and mile scales in the bottom left corner,
+// above bottom.
+func drawScaleBar(dst draw.Image, v *Viewport, bottom int) {
+	mpp := v.metersPerPixel()
+	maxWidth := scaleBarMaxWidth
+	if w := v.Width / 3; w < maxWidth {
+		maxWidth = w
+	}
+	x := dst.Bounds().Min.X + annotationMargin
+	y := bottom - annotationMargin - 2*lineHeight
+	for _, unit := range []struct {
+		name   string
+		meters float64
+	}{{"km", 1000}, {"mi", 1609.344}} {
+		distance := niceDistance(float64(maxWidth) * mpp / unit.meters)
+		width := int(math.Round(distance * unit.meters / mpp))
+		bar := image.Rect(x, y+lineHeight/2-1, x+width, y+lineHeight/2+1)
+		draw.Draw(dst, bar.Inset(-1), image.NewUniform(annotationHalo), image.Point{}, draw.Src)
+		draw.Draw(dst, bar, image.NewUniform(annotationInk), image.Point{}, draw.Src)
+		label := strconv.FormatFloat(distance, 'f', -1, 64) + " " + unit.name
+		drawText(dst, image.Pt(x+width+annotationPadding, y+lineHeight-3), label, annotationInk, annotationHalo)
+		y += lineHeight
+	}
+}
@@ -0,0 +1,69 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNiceDistance(t *testing.T) {
+	assert.Equal(t, 100.0, niceDistance(123))
+	assert.Equal(t, 50.0, niceDistance(99))
+	assert.Equal(t, 2.0, niceDistance(4.9))
+	assert.Equal(t, .5, niceDistance(.7))
+	assert.Equal(t, 0.0, niceDistance(0))
+}
+
+func TestMetersPerPixel(t *testing.T) {
+	v := &Viewport{Zoom: 0}
+	assert.InDelta(t, 156543.03, v.metersPerPixel(), .01)
+	v = &Viewport{Center: geo.Coordinates{Latitude: 60}, Zoom: 1}
+	assert.InDelta(t, 156543.03/4, v.metersPerPixel(), .01)
+}
+
+func TestAttribution(t *testing.T) {
+	osm := &XYZSource{Source: Source{Attribution: "© OpenStreetMap contributors"}}
+	weather := &XYZSource{Source: Source{Attribution: "Weather data"}}
+	assert.Equal(t, "© OpenStreetMap contributors | Weather data", Attribution(
+		&Layer{Source: osm},
+		&Layer{Source: weather},
+		&Layer{Source: weather},
+		&Layer{Source: &solidTiles{}},
+	))
+}
+
+func TestAnnotate(t *testing.T) {
+	austin := geo.Coordinates{Latitude: 30.2672, Longitude: -97.7431}
+	black := color.NRGBA{0, 0, 0, 0xff}
+	v := &Viewport{Center: austin, Zoom: 7, Width: 512, Height: 512}
+	img := imaging.New(v.Width, v.Height, black)
+	swatch := color.NRGBA{0, 0xff, 0, 0xff}
+	Annotate(img, v, &Annotations{
+		Marker:      &austin,
+		Label:       "Austin, TX",
+		ScaleBar:    true,
+		Legends:     []*Legend{{Title: "Rain", Stops: []LegendStop{{Label: "Any", Color: swatch}}}},
+		Attribution: "© OpenStreetMap contributors",
+	})
+
+	at := v.Point(&austin)
+	assert.Equal(t, markerColor, img.NRGBAAt(at.X, at.Y-8), "the pin points at the marker")
+	assert.Equal(t, swatch, img.NRGBAAt(annotationMargin+annotationPadding+6, annotationMargin+annotationPadding+lineHeight+6))
+	assert.NotEqual(t, black, img.NRGBAAt(v.Width-2, v.Height-2), "attribution is drawn on a panel")
+	assert.True(t, changed(img, image.Rect(0, v.Height-40, 60, v.Height), black), "scale bar is drawn")
+}
+
+func changed(img *image.NRGBA, r image.Rectangle, c color.NRGBA) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.NRGBAAt(x, y) != c {
+				return true
+			}
+		}
+	}
+	return false
+}
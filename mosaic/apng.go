@@ -0,0 +1,130 @@
+package mosaic
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EncodeAPNG writes frames to w as a looping animated PNG, which keeps full
+// color and transparency where a GIF is limited to 256 colors. Every frame
+// must be the size of the first.
+func EncodeAPNG(w io.Writer, frames []*image.NRGBA, delay time.Duration) error {
+	if len(frames) == 0 {
+		return errors.New("no frames to encode")
+	}
+	bounds := frames[0].Bounds()
+	e := &apngEncoder{w: w}
+	e.write([]byte("\x89PNG\r\n\x1a\n"))
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(bounds.Dy()))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // truecolor with alpha
+	e.chunk("IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	// zero plays forever
+	binary.BigEndian.PutUint32(actl[4:], 0)
+	e.chunk("acTL", actl)
+
+	milliseconds := delay / time.Millisecond
+	if milliseconds > 0xffff {
+		milliseconds = 0xffff
+	}
+	for i, frame := range frames {
+		if frame.Bounds().Size() != bounds.Size() {
+			return errors.Errorf("frame %d is %v, not %v like the first", i, frame.Bounds().Size(), bounds.Size())
+		}
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], e.sequence())
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		// x and y offsets are zero
+		binary.BigEndian.PutUint16(fctl[20:], uint16(milliseconds))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		// dispose op none, blend op source
+		e.chunk("fcTL", fctl)
+
+		data, err := compressFrame(frame)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compress frame %d", i)
+		}
+		if i == 0 {
+			e.chunk("IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, e.sequence())
+			e.chunk("fdAT", append(fdat, data...))
+		}
+	}
+	e.chunk("IEND", nil)
+	return errors.Wrap(e.err, "failed to write APNG")
+}
+
+type apngEncoder struct {
+	w   io.Writer
+	seq uint32
+	err error
+}
+
+// sequence numbers the fcTL and fdAT chunks.
+func (e *apngEncoder) sequence() uint32 {
+	seq := e.seq
+	e.seq++
+	return seq
+}
+
+func (e *apngEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *apngEncoder) chunk(name string, data []byte) {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc.Sum32())
+	e.write(header)
+	e.write(data)
+	e.write(sum)
+}
+
+// compressFrame returns the zlib compressed scanlines of img, each filtered
+// against the one above it.
+func compressFrame(img *image.NRGBA) ([]byte, error) {
+	b := img.Bounds()
+	rowLength := 4 * b.Dx()
+	buf := new(bytes.Buffer)
+	zw := zlib.NewWriter(buf)
+	row := make([]byte, 1+rowLength)
+	prev := make([]byte, rowLength)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		pixels := img.Pix[img.PixOffset(b.Min.X, y):][:rowLength]
+		row[0] = 2 // the Up filter
+		for i, p := range pixels {
+			row[1+i] = p - prev[i]
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+		copy(prev, pixels)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package mosaic
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BlendMode is how a layer's colors combine with the layers beneath it,
+// following the W3C compositing spec's separable blend modes.
+type BlendMode int
+
+const (
+	// Normal draws the layer over those beneath.
+	Normal BlendMode = iota
+	// Multiply darkens, keeping the basemap's detail under the layer.
+	Multiply
+	// Screen lightens, the inverse of Multiply.
+	Screen
+	// Overlay multiplies dark areas of the layers beneath and screens
+	// light ones.
+	Overlay
+	Darken
+	Lighten
+)
+
+var blendModeNames = []string{"normal", "multiply", "screen", "overlay", "darken", "lighten"}
+
+func (b BlendMode) String() string {
+	if b < 0 || int(b) >= len(blendModeNames) {
+		return "unknown"
+	}
+	return blendModeNames[b]
+}
+
+// ParseBlendMode returns the blend mode named s, case insensitively. An
+// empty name is Normal.
+func ParseBlendMode(s string) (BlendMode, error) {
+	if s == "" {
+		return Normal, nil
+	}
+	for i, name := range blendModeNames {
+		if strings.EqualFold(s, name) {
+			return BlendMode(i), nil
+		}
+	}
+	return Normal, errors.Errorf("unknown blend mode '%s'; expected one of %s", s, strings.Join(blendModeNames, ", "))
+}
+
+func (b BlendMode) blend(backdrop, source float64) float64 {
+	switch b {
+	case Multiply:
+		return backdrop * source
+	case Screen:
+		return backdrop + source - backdrop*source
+	case Overlay:
+		if backdrop <= .5 {
+			return 2 * backdrop * source
+		}
+		return 1 - 2*(1-backdrop)*(1-source)
+	case Darken:
+		return math.Min(backdrop, source)
+	case Lighten:
+		return math.Max(backdrop, source)
+	}
+	return source
+}
+
+// composite draws src over dst in place with opacity between 0 and 1,
+// blending their colors with mode. Both images must be the same size.
+func composite(dst, src *image.NRGBA, opacity float64, mode BlendMode) {
+	opacity = math.Max(0, math.Min(1, opacity))
+	b := dst.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		d := dst.Pix[dst.PixOffset(b.Min.X, y):][:4*b.Dx()]
+		s := src.Pix[src.PixOffset(src.Bounds().Min.X, src.Bounds().Min.Y+y-b.Min.Y):][:4*b.Dx()]
+		for i := 0; i < len(d); i += 4 {
+			as := float64(s[i+3]) / 255 * opacity
+			if as == 0 {
+				continue
+			}
+			ab := float64(d[i+3]) / 255
+			ao := as + ab*(1-as)
+			for c := 0; c < 3; c++ {
+				cs, cb := float64(s[i+c])/255, float64(d[i+c])/255
+				// where there's a backdrop the source takes on the blended color
+				cs = (1-ab)*cs + ab*mode.blend(cb, cs)
+				d[i+c] = uint8(math.Round((as*cs + ab*(1-as)*cb) / ao * 255))
+			}
+			d[i+3] = uint8(math.Round(ao * 255))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package mosaic
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlendMode(t *testing.T) {
+	mode, err := ParseBlendMode("Multiply")
+	require.NoError(t, err)
+	assert.Equal(t, Multiply, mode)
+	mode, err = ParseBlendMode("")
+	require.NoError(t, err)
+	assert.Equal(t, Normal, mode)
+	_, err = ParseBlendMode("dissolve")
+	assert.Error(t, err)
+	assert.Equal(t, "screen", Screen.String())
+}
+
+func TestComposite(t *testing.T) {
+	gray := color.NRGBA{128, 128, 128, 255}
+	red := color.NRGBA{255, 0, 0, 255}
+	for _, tc := range []struct {
+		mode    BlendMode
+		opacity float64
+		want    color.NRGBA
+	}{
+		{Normal, 1, red},
+		{Normal, .5, color.NRGBA{192, 64, 64, 255}},
+		{Multiply, 1, color.NRGBA{128, 0, 0, 255}},
+		{Screen, 1, color.NRGBA{255, 128, 128, 255}},
+		{Overlay, 1, color.NRGBA{255, 1, 1, 255}},
+		{Darken, 1, color.NRGBA{128, 0, 0, 255}},
+		{Lighten, 1, color.NRGBA{255, 128, 128, 255}},
+	} {
+		dst := imaging.New(2, 2, gray)
+		composite(dst, imaging.New(2, 2, red), tc.opacity, tc.mode)
+		assert.Equal(t, tc.want, dst.NRGBAAt(1, 1), "%s at %g", tc.mode, tc.opacity)
+	}
+
+	// transparent source pixels leave the backdrop alone, whatever the mode
+	dst := imaging.New(1, 1, gray)
+	composite(dst, imaging.New(1, 1, color.NRGBA{}), 1, Multiply)
+	assert.Equal(t, gray, dst.NRGBAAt(0, 0))
+
+	// over a transparent backdrop the source keeps its own color
+	dst = imaging.New(1, 1, color.NRGBA{})
+	composite(dst, imaging.New(1, 1, red), 1, Multiply)
+	assert.Equal(t, red, dst.NRGBAAt(0, 0))
+}
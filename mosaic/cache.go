@@ -0,0 +1,376 @@
+package mosaic
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultUserAgent identifies this library to tile servers. The
+// OpenStreetMap tile usage policy forbids generic library User-Agents.
+const DefaultUserAgent = "github.com/gigawhitlocks/weather mosaic"
+
+// DefaultTileTTL is how long a tile is considered fresh when the server
+// sends no caching headers. The OpenStreetMap tile usage policy asks for
+// at least 7 days.
+const DefaultTileTTL = 7 * 24 * time.Hour
+
+// TileCache is an http.RoundTripper that keeps tiles on disk in Dir. Fresh
+// tiles are served without touching the network; stale ones are
+// revalidated with their ETag or Last-Modified date, and served anyway if
+// the server can't be reached or fails with a 5xx status. Requests upstream
+// share MaxConcurrentPerHost with every other cache and Source, and once the
+// cache grows past MaxSize bytes the least recently used tiles are removed. UserAgent replaces
+// DefaultUserAgent on requests from Sources; the OpenStreetMap tile usage
+// policy asks applications to identify themselves with their own.
+//
+// Use it as the Client of a tile Source:
+//
+//	cache, err := mosaic.NewTileCache(dir, 512<<20)
+//	osm := *mosaic.OpenStreetMap
+//	osm.Client = cache.Client()
+type TileCache struct {
+	Dir       string
+	MaxSize   int64
+	UserAgent string
+	Transport http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	size    int64
+}
+
+// tileMetadata is stored next to each cached tile.
+type tileMetadata struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"last_modified"`
+	ContentType  string    `json:"content_type"`
+	Expires      time.Time `json:"expires"`
+	Size         int64     `json:"size"`
+}
+
+type cachedTile struct {
+	key  string
+	size int64
+}
+
+var _ http.RoundTripper = &TileCache{}
+
+// NewTileCache opens or creates a cache in dir holding up to maxSize bytes.
+// Tiles already in dir count towards the limit, oldest access first.
+func NewTileCache(dir string, maxSize int64) (*TileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create tile cache directory")
+	}
+	c := &TileCache{
+		Dir:       dir,
+		MaxSize:   maxSize,
+		UserAgent: DefaultUserAgent,
+		entries:   map[string]*list.Element{},
+		order:     list.New(),
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read tile cache directory")
+	}
+	tiles := []os.FileInfo{}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".tile") {
+			tiles = append(tiles, f)
+		}
+	}
+	// oldest first, so the most recently used ends up at the front
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i].ModTime().Before(tiles[j].ModTime()) })
+	for _, f := range tiles {
+		key := strings.TrimSuffix(f.Name(), ".tile")
+		c.entries[key] = c.order.PushFront(&cachedTile{key: key, size: f.Size()})
+		c.size += f.Size()
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+var (
+	sharedMu     sync.Mutex
+	sharedCaches = map[string]*TileCache{}
+)
+
+// SharedTileCache returns the process's cache in dir, opening it with
+// NewTileCache the first time. Caches must not share a directory, since
+// each keeps its own account of the size, recency and concurrency of the
+// tiles in it; use this wherever a directory may be used more than once.
+// maxSize only applies when the cache is opened.
+func SharedTileCache(dir string, maxSize int64) (*TileCache, error) {
+	key, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve tile cache directory")
+	}
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if c, ok := sharedCaches[key]; ok {
+		return c, nil
+	}
+	c, err := NewTileCache(key, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	sharedCaches[key] = c
+	return c, nil
+}
+
+// Client returns an HTTP client that fetches through the cache.
+func (c *TileCache) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// Size returns the number of bytes of tiles in the cache.
+func (c *TileCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *TileCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return c.upstream(req)
+	}
+	key := cacheKey(req.URL.String())
+	meta, body := c.load(key)
+	if meta != nil && time.Now().Before(meta.Expires) {
+		return cachedResponse(req, meta, body), nil
+	}
+
+	outgoing := req
+	if meta != nil {
+		outgoing = req.Clone(req.Context())
+		if meta.ETag != "" {
+			outgoing.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+	resp, err := c.upstream(outgoing)
+	if meta != nil && (err != nil || resp.StatusCode >= 500) {
+		// a stale tile beats no tile
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return cachedResponse(req, meta, body), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && meta != nil:
+		resp.Body.Close()
+		meta.Expires = expires(resp.Header)
+		if err := c.store(key, meta, body); err != nil {
+			return nil, err
+		}
+		return cachedResponse(req, meta, body), nil
+	case resp.StatusCode == http.StatusOK && cacheable(resp.Header):
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read tile")
+		}
+		meta = &tileMetadata{
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			Expires:      expires(resp.Header),
+			Size:         int64(len(body)),
+		}
+		if err := c.store(key, meta, body); err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// upstream sends req with the cache's User-Agent, unless the request has
+// one of its own, waiting for a slot for its host.
+func (c *TileCache) upstream(req *http.Request) (*http.Response, error) {
+	req, release := holdSlot(req)
+	defer release()
+
+	// Sources send DefaultUserAgent unless they're configured with their
+	// own, so the cache's more specific one replaces it
+	if ua := req.Header.Get("User-Agent"); ua == "" || ua == DefaultUserAgent {
+		want := c.UserAgent
+		if want == "" {
+			want = DefaultUserAgent
+		}
+		if ua != want {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", want)
+		}
+	}
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(req)
+}
+
+func (c *TileCache) path(key, ext string) string {
+	return filepath.Join(c.Dir, key+ext)
+}
+
+func (c *TileCache) load(key string) (*tileMetadata, []byte) {
+	raw, err := ioutil.ReadFile(c.path(key, ".json"))
+	if err != nil {
+		return nil, nil
+	}
+	meta := new(tileMetadata)
+	if err = json.Unmarshal(raw, meta); err != nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadFile(c.path(key, ".tile"))
+	if err != nil || int64(len(body)) != meta.Size {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	now := time.Now()
+	os.Chtimes(c.path(key, ".tile"), now, now)
+	return meta, body
+}
+
+func (c *TileCache) store(key string, meta *tileMetadata, body []byte) error {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(c.path(key, ".tile"), body); err != nil {
+		return errors.Wrap(err, "failed to write tile to cache")
+	}
+	if err = writeFileAtomic(c.path(key, ".json"), raw); err != nil {
+		return errors.Wrap(err, "failed to write tile metadata to cache")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.size -= e.Value.(*cachedTile).size
+		e.Value.(*cachedTile).size = meta.Size
+		c.order.MoveToFront(e)
+	} else {
+		c.entries[key] = c.order.PushFront(&cachedTile{key: key, size: meta.Size})
+	}
+	c.size += meta.Size
+	c.evict()
+	return nil
+}
+
+// evict removes least recently used tiles until the cache fits in MaxSize.
+// The caller must hold c.mu.
+func (c *TileCache) evict() {
+	for c.MaxSize > 0 && c.size > c.MaxSize && c.order.Len() > 1 {
+		oldest := c.order.Back()
+		tile := oldest.Value.(*cachedTile)
+		c.order.Remove(oldest)
+		delete(c.entries, tile.key)
+		c.size -= tile.size
+		os.Remove(c.path(tile.key, ".tile"))
+		os.Remove(c.path(tile.key, ".json"))
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func cacheable(h http.Header) bool {
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store")
+}
+
+// expires works out when a response goes stale from its Cache-Control
+// max-age or Expires header, defaulting to DefaultTileTTL.
+func expires(h http.Header) time.Time {
+	now := time.Now()
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		if directive == "no-cache" {
+			return now
+		}
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+	if e := h.Get("Expires"); e != "" {
+		if t, err := http.ParseTime(e); err == nil {
+			return t
+		}
+	}
+	return now.Add(DefaultTileTTL)
+}
+
+func cachedResponse(req *http.Request, meta *tileMetadata, body []byte) *http.Response {
+	h := http.Header{}
+	if meta.ContentType != "" {
+		h.Set("Content-Type", meta.ContentType)
+	}
+	if meta.ETag != "" {
+		h.Set("ETag", meta.ETag)
+	}
+	h.Set("X-Cache", "HIT")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
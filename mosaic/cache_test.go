@@ -0,0 +1,225 @@
+package mosaic
+
+import (
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mosaic")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestTileCacheServesFreshTiles(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Equal(t, DefaultUserAgent, r.Header.Get("User-Agent"))
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte("tile"))
+	}))
+	defer server.Close()
+
+	cache, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	_, body := get(t, cache.Client(), server.URL+"/1/0/0.png")
+	assert.Equal(t, "tile", body)
+	resp, body := get(t, cache.Client(), server.URL+"/1/0/0.png")
+	assert.Equal(t, "tile", body)
+	assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// a new cache in the same directory picks up what's on disk
+	reopened, err := NewTileCache(cache.Dir, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), reopened.Size())
+	get(t, reopened.Client(), server.URL+"/1/0/0.png")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestTileCacheRevalidates(t *testing.T) {
+	var hits, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Expires", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte("tile"))
+	}))
+	defer server.Close()
+
+	cache, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	get(t, cache.Client(), server.URL+"/tile.png")
+	resp, body := get(t, cache.Client(), server.URL+"/tile.png")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "tile", body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+}
+
+func TestTileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	cache, err := NewTileCache(tempDir(t), 25)
+	require.NoError(t, err)
+	client := cache.Client()
+	get(t, client, server.URL+"/a")
+	get(t, client, server.URL+"/b")
+	get(t, client, server.URL+"/a")
+	get(t, client, server.URL+"/c")
+	assert.Equal(t, int64(20), cache.Size())
+
+	meta, _ := cache.load(cacheKey(server.URL + "/b"))
+	assert.Nil(t, meta)
+	meta, _ = cache.load(cacheKey(server.URL + "/a"))
+	assert.NotNil(t, meta)
+}
+
+func TestTileCacheServesStaleTilesOnServerErrors(t *testing.T) {
+	var failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte("tile"))
+	}))
+
+	cache, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	get(t, cache.Client(), server.URL+"/tile.png")
+
+	atomic.StoreInt32(&failing, 1)
+	resp, body := get(t, cache.Client(), server.URL+"/tile.png")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "tile", body)
+
+	server.Close()
+	resp, body = get(t, cache.Client(), server.URL+"/tile.png")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "tile", body)
+
+	_, err = cache.Client().Get(server.URL + "/other.png")
+	assert.Error(t, err, "without a stale tile the error is returned")
+}
+
+func TestSharedTileCache(t *testing.T) {
+	dir := tempDir(t)
+	a, err := SharedTileCache(dir, 1<<20)
+	require.NoError(t, err)
+	b, err := SharedTileCache(dir+"/.", 1<<10)
+	require.NoError(t, err)
+	assert.Same(t, a, b)
+	assert.Equal(t, int64(1<<20), b.MaxSize)
+
+	c, err := SharedTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	assert.NotSame(t, a, c)
+}
+
+func TestTileCacheUserAgent(t *testing.T) {
+	agents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.Header.Get("User-Agent")
+		png.Encode(w, imaging.New(256, 256, color.White))
+	}))
+	defer server.Close()
+
+	cache, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	cache.UserAgent = "weather-bot/1.0 (+https://example.com)"
+	s := &XYZSource{
+		Source:      Source{Name: "test", Client: cache.Client()},
+		URLTemplate: server.URL + "/{z}/{x}/{y}.png",
+	}
+	_, err = s.Tile(geo.NewSlippyMapTile(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, cache.UserAgent, <-agents, "the cache's User-Agent replaces the default")
+
+	s.Headers = map[string]string{"User-Agent": "custom"}
+	_, err = s.Tile(geo.NewSlippyMapTile(1, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "custom", <-agents, "a source's own User-Agent is kept")
+}
+
+func TestTileCacheLimitsConcurrency(t *testing.T) {
+	var active, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		w.Write([]byte("tile"))
+	}))
+	defer server.Close()
+
+	first, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	second, err := NewTileCache(tempDir(t), 1<<20)
+	require.NoError(t, err)
+	direct := &XYZSource{URLTemplate: server.URL + "/{z}/{x}/{y}"}
+	cached := &XYZSource{URLTemplate: server.URL + "/{z}/{x}/{y}", Source: Source{Client: first.Client()}}
+	fetches := []func(i int){
+		func(i int) {
+			if resp, err := first.Client().Get(server.URL + "/" + string(rune('a'+i))); err == nil {
+				resp.Body.Close()
+			}
+		},
+		func(i int) {
+			if resp, err := second.Client().Get(server.URL + "/" + string(rune('a'+i))); err == nil {
+				resp.Body.Close()
+			}
+		},
+		func(i int) { direct.Tile(geo.NewSlippyMapTile(i, 0, 4)) },
+		func(i int) { cached.Tile(geo.NewSlippyMapTile(i, 1, 4)) },
+	}
+	done := make(chan struct{})
+	for i := 0; i < 16; i++ {
+		go func(i int) {
+			fetches[i%len(fetches)](i)
+			done <- struct{}{}
+		}(i)
+	}
+	for i := 0; i < 16; i++ {
+		<-done
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(MaxConcurrentPerHost),
+		"caches and sources share the limit for a host")
+}
@@ -0,0 +1,39 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+)
+
+// ImageFormat is a still image format.
+type ImageFormat string
+
+const (
+	PNG  ImageFormat = "png"
+	JPEG ImageFormat = "jpeg"
+)
+
+// JPEGQuality is the quality maps are encoded at as JPEG.
+var JPEGQuality = 85
+
+// Encode writes img to w in format. JPEG has no transparency, so
+// transparent areas are drawn on white.
+func Encode(w io.Writer, img *image.NRGBA, format ImageFormat) error {
+	switch ImageFormat(strings.ToLower(string(format))) {
+	case PNG, "":
+		return errors.Wrap(png.Encode(w, img), "failed to encode PNG")
+	case JPEG, "jpg":
+		b := img.Bounds()
+		background := imaging.New(b.Dx(), b.Dy(), color.White)
+		flat := imaging.Overlay(background, img, image.Pt(0, 0), 1)
+		return errors.Wrap(jpeg.Encode(w, flat, &jpeg.Options{Quality: JPEGQuality}), "failed to encode JPEG")
+	}
+	return errors.Errorf("unknown image format '%s'", format)
+}
@@ -0,0 +1,28 @@
+package mosaic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	img := imaging.New(4, 4, color.NRGBA{0, 0, 0, 0})
+	for _, format := range []ImageFormat{PNG, JPEG, "JPG", ""} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, Encode(buf, img, format), format)
+		_, name, err := image.Decode(buf)
+		require.NoError(t, err)
+		if format == PNG || format == "" {
+			assert.Equal(t, "png", name)
+		} else {
+			assert.Equal(t, "jpeg", name)
+		}
+	}
+	assert.Error(t, Encode(new(bytes.Buffer), img, "tiff"))
+}
@@ -0,0 +1,110 @@
+// Package mbtiles reads offline basemaps from MBTiles archives. It needs
+// cgo for SQLite, so it's kept out of the mosaic package; importing it lets
+// mosaic.LoadSources open sources with a type of "mbtiles".
+package mbtiles
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"net/url"
+	"strconv"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/mosaic"
+	_ "github.com/mattn/go-sqlite3" // MBTiles archives are SQLite databases
+	"github.com/pkg/errors"
+)
+
+func init() {
+	mosaic.RegisterArchive("mbtiles", func(path string) (mosaic.TileSource, error) { return Open(path) })
+}
+
+var _ mosaic.TileSource = &Archive{}
+
+// Archive reads raster tiles from an MBTiles archive, a SQLite database of
+// tiles that can be packaged with the service as an offline basemap. Zoom
+// range, attribution and name come from the archive's metadata table.
+type Archive struct {
+	mosaic.Source
+	// Format is the tile format recorded in the archive, "png" or "jpg".
+	Format string
+
+	db *sql.DB
+}
+
+// Open opens the archive at path read-only.
+func Open(path string) (*Archive, error) {
+	db, err := sql.Open("sqlite3", "file:"+(&url.URL{Path: path}).EscapedPath()+"?mode=ro")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	m := &Archive{Source: mosaic.Source{Name: path}, db: db}
+	if err = m.readMetadata(); err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to read metadata from %s", path)
+	}
+	switch m.Format {
+	case "png", "jpg", "jpeg", "":
+	default:
+		db.Close()
+		return nil, errors.Errorf("%s holds %s tiles; only raster archives are supported", path, m.Format)
+	}
+	return m, nil
+}
+
+func (m *Archive) readMetadata() error {
+	rows, err := m.db.Query("SELECT name, value FROM metadata")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return err
+		}
+		switch name {
+		case "name":
+			m.Name = value
+		case "format":
+			m.Format = value
+		case "attribution":
+			m.Attribution = value
+		case "minzoom":
+			m.MinZoom, _ = strconv.Atoi(value)
+		case "maxzoom":
+			m.MaxZoom, _ = strconv.Atoi(value)
+		}
+	}
+	return rows.Err()
+}
+
+// Tile reads tile from the archive, returning nil if the archive doesn't
+// have it.
+func (m *Archive) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	if !m.InZoomRange(tile.Z) {
+		return nil, nil
+	}
+	var data []byte
+	err := m.db.QueryRow(
+		"SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		tile.Z, tile.X, tile.TMSY(),
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read tile %s from %s", tile, m.Name)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode tile %s from %s", tile, m.Name)
+	}
+	return img, nil
+}
+
+// Close closes the archive.
+func (m *Archive) Close() error {
+	return m.db.Close()
+}
@@ -0,0 +1,104 @@
+package mbtiles
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/mosaic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mbtiles")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func pngTile(t *testing.T, c color.Color) []byte {
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, imaging.New(256, 256, c)))
+	return buf.Bytes()
+}
+
+func writeMBTiles(t *testing.T, path string, metadata map[string]string, tiles map[[3]int][]byte) {
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE metadata (name text, value text);
+		CREATE TABLE tiles (zoom_level integer, tile_column integer, tile_row integer, tile_data blob)`)
+	require.NoError(t, err)
+	for k, v := range metadata {
+		_, err = db.Exec("INSERT INTO metadata VALUES (?, ?)", k, v)
+		require.NoError(t, err)
+	}
+	for zxy, data := range tiles {
+		_, err = db.Exec("INSERT INTO tiles VALUES (?, ?, ?, ?)", zxy[0], zxy[1], zxy[2], data)
+		require.NoError(t, err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	path := filepath.Join(tempDir(t), "basemap.mbtiles")
+	writeMBTiles(t, path, map[string]string{
+		"name":        "basemap",
+		"format":      "png",
+		"minzoom":     "0",
+		"maxzoom":     "1",
+		"attribution": "© test",
+	}, map[[3]int][]byte{
+		// rows are stored bottom up: the top-right tile at zoom 1 is row 1
+		{1, 1, 1}: pngTile(t, color.White),
+	})
+
+	m, err := Open(path)
+	require.NoError(t, err)
+	defer m.Close()
+	assert.Equal(t, "basemap", m.Name)
+	assert.Equal(t, "© test", m.Attribution)
+	assert.Equal(t, 1, m.MaxZoom)
+
+	img, err := m.Tile(geo.NewSlippyMapTile(1, 0, 1))
+	require.NoError(t, err)
+	require.NotNil(t, img)
+	assert.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+
+	img, err = m.Tile(geo.NewSlippyMapTile(1, 1, 1))
+	assert.NoError(t, err)
+	assert.Nil(t, img, "missing tiles are transparent")
+
+	img, err = m.Tile(geo.NewSlippyMapTile(0, 0, 2))
+	assert.NoError(t, err)
+	assert.Nil(t, img, "tiles beyond MaxZoom are transparent")
+}
+
+func TestArchiveRejectsVectorTiles(t *testing.T) {
+	path := filepath.Join(tempDir(t), "vector.mbtiles")
+	writeMBTiles(t, path, map[string]string{"format": "pbf"}, nil)
+	_, err := Open(path)
+	assert.Error(t, err)
+}
+
+func TestLoadSourcesArchive(t *testing.T) {
+	path := filepath.Join(tempDir(t), "basemap.mbtiles")
+	writeMBTiles(t, path, map[string]string{"name": "packaged", "format": "png"}, nil)
+
+	sources, err := mosaic.LoadSources(strings.NewReader(
+		`[{"type": "mbtiles", "name": "offline", "path": "` + path + `"}]`))
+	require.NoError(t, err)
+	require.IsType(t, &Archive{}, sources["offline"])
+	m := sources["offline"].(*Archive)
+	defer m.Close()
+	assert.Equal(t, "offline", m.Name)
+}
@@ -0,0 +1,205 @@
+// Package mosaic renders maps from slippy map tiles. It fetches the tiles
+// covering a viewport from any number of tile sources, crops them to the
+// exact viewport and blends the layers together.
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+)
+
+// MaxConcurrentFetches bounds how many tiles Render fetches at once, across
+// all of its layers.
+var MaxConcurrentFetches = 8
+
+// TileSource provides the image for a tile. Sources may return images of
+// any size; they are scaled to the viewport's tile size.
+type TileSource interface {
+	Tile(tile *geo.SlippyMapTile) (image.Image, error)
+}
+
+// TileSourceFunc adapts a function to a TileSource.
+type TileSourceFunc func(tile *geo.SlippyMapTile) (image.Image, error)
+
+func (f TileSourceFunc) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	return f(tile)
+}
+
+// Viewport is the area of the map to render: Width by Height pixels
+// centered on Center at Zoom.
+type Viewport struct {
+	Center   geo.Coordinates
+	Zoom     int
+	Width    int
+	Height   int
+	TileSize int
+}
+
+func (v *Viewport) tileSize() int {
+	if v.TileSize > 0 {
+		return v.TileSize
+	}
+	return geo.TileSize
+}
+
+// Origin returns the global pixel coordinates of the viewport's top left
+// corner.
+func (v *Viewport) Origin() (x, y float64) {
+	cx, cy := geo.CoordinatesToPixel(&v.Center, v.Zoom, v.tileSize())
+	// round rather than truncate so viewports centered on a tile boundary
+	// line up with the tiles despite floating point error
+	return math.Round(cx - float64(v.Width)/2), math.Round(cy - float64(v.Height)/2)
+}
+
+// Point returns where c falls in the rendered image, which may be outside
+// it. Points across the antimeridian are placed on the side nearest the
+// center.
+func (v *Viewport) Point(c *geo.Coordinates) image.Point {
+	ox, oy := v.Origin()
+	x, y := geo.CoordinatesToPixel(c, v.Zoom, v.tileSize())
+	world := float64(v.tileSize() << uint(v.Zoom))
+	cx, _ := geo.CoordinatesToPixel(&v.Center, v.Zoom, v.tileSize())
+	if x-cx > world/2 {
+		x -= world
+	} else if cx-x > world/2 {
+		x += world
+	}
+	return image.Pt(int(math.Floor(x-ox)), int(math.Floor(y-oy)))
+}
+
+// Tiles returns the range of tiles covering the viewport. X is not wrapped,
+// so the range may extend past either side of the map.
+func (v *Viewport) Tiles() *geo.TileRange {
+	ox, oy := v.Origin()
+	size := float64(v.tileSize())
+	return &geo.TileRange{
+		Z:    v.Zoom,
+		MinX: int(math.Floor(ox / size)),
+		MinY: int(math.Floor(oy / size)),
+		MaxX: int(math.Floor((ox + float64(v.Width) - 1) / size)),
+		MaxY: int(math.Floor((oy + float64(v.Height) - 1) / size)),
+	}
+}
+
+// Layer is a tile source drawn over the layers beneath it with Opacity
+// between 0 and 1, its colors combined with theirs by Blend.
+type Layer struct {
+	Source  TileSource
+	Opacity float64
+	Blend   BlendMode
+}
+
+// Render draws each layer in order, the first at full opacity, into an image
+// the size of the viewport.
+func Render(v *Viewport, layers ...*Layer) (*image.NRGBA, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("no layers to render")
+	}
+	if v.Width <= 0 || v.Height <= 0 {
+		return nil, errors.Errorf("invalid viewport size %dx%d", v.Width, v.Height)
+	}
+
+	images := make([]*image.NRGBA, len(layers))
+	errs := make([]error, len(layers))
+	sem := newFetchLimit()
+	wg := sync.WaitGroup{}
+	for i, layer := range layers {
+		wg.Add(1)
+		go func(i int, layer *Layer) {
+			defer wg.Done()
+			images[i], errs[i] = renderLayer(v, layer.Source, sem)
+		}(i, layer)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render layer %d", i)
+		}
+	}
+
+	dst := images[0]
+	for i, layer := range layers[1:] {
+		composite(dst, images[i+1], layer.Opacity, layer.Blend)
+	}
+	return dst, nil
+}
+
+// RenderLayer fetches the tiles covering the viewport from source and
+// crops them to it. Areas beyond the top and bottom of the map are left
+// transparent.
+func RenderLayer(v *Viewport, source TileSource) (*image.NRGBA, error) {
+	return renderLayer(v, source, newFetchLimit())
+}
+
+// renderLayer is RenderLayer fetching no more tiles at once than sem has
+// room for.
+func renderLayer(v *Viewport, source TileSource, sem chan struct{}) (*image.NRGBA, error) {
+	if source == nil {
+		return nil, errors.New("no tile source")
+	}
+	size := v.tileSize()
+	ox, oy := v.Origin()
+	tiles := v.Tiles()
+	dst := imaging.New(v.Width, v.Height, color.NRGBA{0, 0, 0, 0})
+
+	type fetched struct {
+		image image.Image
+		at    image.Point
+		err   error
+	}
+	results := make(chan fetched)
+	count := 0
+	for y := tiles.MinY; y <= tiles.MaxY; y++ {
+		if y < 0 || y >= 1<<uint(v.Zoom) {
+			continue
+		}
+		for x := tiles.MinX; x <= tiles.MaxX; x++ {
+			count++
+			at := image.Pt(x*size-int(ox), y*size-int(oy))
+			go func(tile *geo.SlippyMapTile, at image.Point) {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				img, err := source.Tile(tile)
+				if err != nil {
+					err = errors.Wrapf(err, "failed to fetch tile %s", tile)
+				}
+				results <- fetched{image: img, at: at, err: err}
+			}(geo.NewSlippyMapTile(x, y, v.Zoom), at)
+		}
+	}
+
+	var err error
+	for i := 0; i < count; i++ {
+		r := <-results
+		if r.err != nil {
+			err = r.err
+			continue
+		}
+		if r.image == nil {
+			continue
+		}
+		img := r.image
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			img = imaging.Resize(img, size, size, imaging.Lanczos)
+		}
+		dst = imaging.Paste(dst, img, r.at)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// newFetchLimit returns a semaphore with room for MaxConcurrentFetches.
+func newFetchLimit() chan struct{} {
+	if MaxConcurrentFetches < 1 {
+		return make(chan struct{}, 1)
+	}
+	return make(chan struct{}, MaxConcurrentFetches)
+}
@@ -0,0 +1,109 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solidTiles returns tiles filled with a color encoding their X and Y, and
+// records which tiles were requested.
+type solidTiles struct {
+	sync.Mutex
+	size      int
+	requested []string
+}
+
+func (s *solidTiles) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	s.Lock()
+	s.requested = append(s.requested, tile.String())
+	s.Unlock()
+	return imaging.New(s.size, s.size, color.NRGBA{uint8(tile.X), uint8(tile.Y), 0, 255}), nil
+}
+
+func TestRenderLayer(t *testing.T) {
+	source := &solidTiles{size: 256}
+	// the center of tile 1/1 at zoom 2 is the corner of four tiles
+	v := &Viewport{Center: geo.Coordinates{Latitude: 0, Longitude: -90}, Zoom: 2, Width: 100, Height: 60}
+	img, err := RenderLayer(v, source)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 100, 60), img.Bounds())
+	assert.ElementsMatch(t, []string{"2/0/1", "2/1/1", "2/0/2", "2/1/2"}, source.requested)
+	assert.Equal(t, color.NRGBA{0, 1, 0, 255}, img.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{1, 2, 0, 255}, img.NRGBAAt(99, 59))
+	assert.Equal(t, image.Pt(50, 30), v.Point(&v.Center))
+}
+
+func TestRenderLayerWrapsAndScales(t *testing.T) {
+	source := &solidTiles{size: 512}
+	v := &Viewport{Center: geo.Coordinates{Latitude: 0, Longitude: 180}, Zoom: 1, Width: 200, Height: 200}
+	img, err := RenderLayer(v, source)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1/1/0", "1/0/0", "1/1/1", "1/0/1"}, source.requested)
+	assert.Equal(t, color.NRGBA{1, 0, 0, 255}, img.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{0, 1, 0, 255}, img.NRGBAAt(199, 199))
+	assert.Equal(t, image.Pt(104, 100), v.Point(&geo.Coordinates{Longitude: -177.1875}))
+}
+
+func TestRenderLayerBeyondPoles(t *testing.T) {
+	v := &Viewport{Center: geo.Coordinates{Latitude: 85}, Zoom: 0, Width: 256, Height: 256}
+	img, err := RenderLayer(v, &solidTiles{size: 256})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), img.NRGBAAt(128, 0).A)
+	assert.Equal(t, uint8(255), img.NRGBAAt(128, 255).A)
+}
+
+func TestRender(t *testing.T) {
+	white := TileSourceFunc(func(*geo.SlippyMapTile) (image.Image, error) {
+		return imaging.New(256, 256, color.White), nil
+	})
+	black := TileSourceFunc(func(*geo.SlippyMapTile) (image.Image, error) {
+		return imaging.New(256, 256, color.Black), nil
+	})
+	v := &Viewport{Zoom: 3, Width: 300, Height: 300}
+	img, err := Render(v, &Layer{Source: white}, &Layer{Source: black, Opacity: 0.5})
+	require.NoError(t, err)
+	c := img.NRGBAAt(150, 150)
+	assert.InDelta(t, 128, int(c.R), 1)
+
+	failing := TileSourceFunc(func(*geo.SlippyMapTile) (image.Image, error) {
+		return nil, errors.New("no tiles here")
+	})
+	_, err = Render(v, &Layer{Source: white}, &Layer{Source: failing})
+	assert.Error(t, err)
+	_, err = Render(v)
+	assert.Error(t, err)
+}
+
+func TestRenderSharesFetchLimit(t *testing.T) {
+	defer func(n int) { MaxConcurrentFetches = n }(MaxConcurrentFetches)
+	MaxConcurrentFetches = 2
+
+	mu := sync.Mutex{}
+	fetching, most := 0, 0
+	source := TileSourceFunc(func(*geo.SlippyMapTile) (image.Image, error) {
+		mu.Lock()
+		fetching++
+		if fetching > most {
+			most = fetching
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		fetching--
+		mu.Unlock()
+		return imaging.New(256, 256, color.White), nil
+	})
+	v := &Viewport{Zoom: 3, Width: 300, Height: 300}
+	_, err := Render(v, &Layer{Source: source}, &Layer{Source: source}, &Layer{Source: source})
+	require.NoError(t, err)
+	assert.Equal(t, 2, most, "every layer shares MaxConcurrentFetches")
+}
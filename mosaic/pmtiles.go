@@ -0,0 +1,279 @@
+package mosaic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+)
+
+const pmtilesHeaderLength = 127
+
+// PMTiles compression and tile type codes, from the version 3 spec.
+const (
+	pmtilesCompressionNone = 1
+	pmtilesCompressionGzip = 2
+
+	pmtilesTilePNG  = 2
+	pmtilesTileJPEG = 3
+)
+
+// pmtilesMaxDepth bounds how many leaf directories a lookup follows.
+const pmtilesMaxDepth = 4
+
+var _ TileSource = &PMTiles{}
+
+// PMTiles reads raster tiles from a version 3 PMTiles archive, a single
+// file of tiles indexed by Hilbert curve that can be packaged with the
+// service as an offline basemap. Internal directories may be gzipped;
+// tiles must be uncompressed PNG or JPEG.
+type PMTiles struct {
+	Source
+
+	r      io.ReaderAt
+	closer io.Closer
+	header pmtilesHeader
+	root   []pmtilesEntry
+}
+
+type pmtilesHeader struct {
+	RootOffset, RootLength uint64
+	LeafOffset, LeafLength uint64
+	DataOffset, DataLength uint64
+	InternalCompression    uint8
+	TileCompression        uint8
+	TileType               uint8
+	MinZoom, MaxZoom       uint8
+}
+
+type pmtilesEntry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint32
+	RunLength uint32
+}
+
+// OpenPMTiles opens the archive at path.
+func OpenPMTiles(path string) (*PMTiles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	p, err := NewPMTiles(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	p.Name = path
+	p.closer = f
+	return p, nil
+}
+
+// NewPMTiles reads an archive from r.
+func NewPMTiles(r io.ReaderAt) (*PMTiles, error) {
+	buf := make([]byte, pmtilesHeaderLength)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, errors.Wrap(err, "failed to read PMTiles header")
+	}
+	if string(buf[:7]) != "PMTiles" {
+		return nil, errors.New("not a PMTiles archive")
+	}
+	if buf[7] != 3 {
+		return nil, errors.Errorf("unsupported PMTiles version %d", buf[7])
+	}
+	le := binary.LittleEndian
+	h := pmtilesHeader{
+		RootOffset:          le.Uint64(buf[8:]),
+		RootLength:          le.Uint64(buf[16:]),
+		LeafOffset:          le.Uint64(buf[40:]),
+		LeafLength:          le.Uint64(buf[48:]),
+		DataOffset:          le.Uint64(buf[56:]),
+		DataLength:          le.Uint64(buf[64:]),
+		InternalCompression: buf[97],
+		TileCompression:     buf[98],
+		TileType:            buf[99],
+		MinZoom:             buf[100],
+		MaxZoom:             buf[101],
+	}
+	if h.TileType != pmtilesTilePNG && h.TileType != pmtilesTileJPEG {
+		return nil, errors.Errorf("unsupported PMTiles tile type %d; only PNG and JPEG are supported", h.TileType)
+	}
+	if h.TileCompression > pmtilesCompressionNone {
+		return nil, errors.Errorf("unsupported PMTiles tile compression %d", h.TileCompression)
+	}
+
+	p := &PMTiles{
+		Source: Source{Name: "PMTiles", MinZoom: int(h.MinZoom), MaxZoom: int(h.MaxZoom)},
+		r:      r,
+		header: h,
+	}
+	var err error
+	if p.root, err = p.directory(h.RootOffset, h.RootLength); err != nil {
+		return nil, errors.Wrap(err, "failed to read root directory")
+	}
+	return p, nil
+}
+
+// directory reads and decodes the directory at offset.
+func (p *PMTiles) directory(offset, length uint64) ([]pmtilesEntry, error) {
+	raw := make([]byte, length)
+	if _, err := p.r.ReadAt(raw, int64(offset)); err != nil {
+		return nil, err
+	}
+	switch p.header.InternalCompression {
+	case pmtilesCompressionNone:
+	case pmtilesCompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = ioutil.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unsupported PMTiles directory compression %d", p.header.InternalCompression)
+	}
+	return decodePMTilesDirectory(raw)
+}
+
+// decodePMTilesDirectory decodes a directory: an entry count followed by
+// columns of delta-coded tile IDs, run lengths, lengths and offsets.
+func decodePMTilesDirectory(raw []byte) ([]pmtilesEntry, error) {
+	r := bytes.NewReader(raw)
+	next := func() (uint64, error) {
+		v, err := binary.ReadUvarint(r)
+		return v, errors.Wrap(err, "truncated PMTiles directory")
+	}
+	n, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(raw)) {
+		return nil, errors.Errorf("PMTiles directory claims %d entries", n)
+	}
+	entries := make([]pmtilesEntry, n)
+	var id uint64
+	for i := range entries {
+		delta, err := next()
+		if err != nil {
+			return nil, err
+		}
+		id += delta
+		entries[i].TileID = id
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		entries[i].RunLength = uint32(v)
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Length = uint32(v)
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if v == 0 && i > 0 {
+			entries[i].Offset = entries[i-1].Offset + uint64(entries[i-1].Length)
+		} else {
+			entries[i].Offset = v - 1
+		}
+	}
+	return entries, nil
+}
+
+// findPMTilesEntry returns the entry covering id: the last entry starting
+// at or before id, if it is a leaf directory or its run reaches id.
+func findPMTilesEntry(entries []pmtilesEntry, id uint64) *pmtilesEntry {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].TileID > id }) - 1
+	if i < 0 {
+		return nil
+	}
+	e := &entries[i]
+	if e.RunLength == 0 || id < e.TileID+uint64(e.RunLength) {
+		return e
+	}
+	return nil
+}
+
+// pmtilesTileID numbers tiles along a Hilbert curve within each zoom
+// level, after all the tiles of lower zooms.
+func pmtilesTileID(z, x, y int) uint64 {
+	var id uint64
+	for i := 0; i < z; i++ {
+		id += uint64(1) << (2 * uint(i))
+	}
+	n := uint64(1) << uint(z)
+	tx, ty := uint64(x), uint64(y)
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry uint64
+		if tx&s > 0 {
+			rx = 1
+		}
+		if ty&s > 0 {
+			ry = 1
+		}
+		id += s * s * ((3 * rx) ^ ry)
+		if ry == 0 {
+			if rx == 1 {
+				tx, ty = n-1-tx, n-1-ty
+			}
+			tx, ty = ty, tx
+		}
+	}
+	return id
+}
+
+// Tile reads tile from the archive, returning nil if the archive doesn't
+// have it.
+func (p *PMTiles) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	if !p.InZoomRange(tile.Z) {
+		return nil, nil
+	}
+	id := pmtilesTileID(tile.Z, tile.X, tile.Y)
+	entries := p.root
+	for depth := 0; depth < pmtilesMaxDepth; depth++ {
+		e := findPMTilesEntry(entries, id)
+		if e == nil {
+			return nil, nil
+		}
+		if e.RunLength > 0 {
+			data := make([]byte, e.Length)
+			if _, err := p.r.ReadAt(data, int64(p.header.DataOffset+e.Offset)); err != nil {
+				return nil, errors.Wrapf(err, "failed to read tile %s from %s", tile, p.Name)
+			}
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode tile %s from %s", tile, p.Name)
+			}
+			return img, nil
+		}
+		var err error
+		if entries, err = p.directory(p.header.LeafOffset+e.Offset, uint64(e.Length)); err != nil {
+			return nil, errors.Wrapf(err, "failed to read leaf directory from %s", p.Name)
+		}
+	}
+	return nil, errors.Errorf("%s nests leaf directories too deeply", p.Name)
+}
+
+// Close closes the archive if it was opened with OpenPMTiles.
+func (p *PMTiles) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
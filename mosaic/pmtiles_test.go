@@ -0,0 +1,145 @@
+package mosaic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pngTile(t *testing.T, c color.Color) []byte {
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, imaging.New(256, 256, c)))
+	return buf.Bytes()
+}
+
+func encodePMTilesDirectory(t *testing.T, entries []pmtilesEntry) []byte {
+	raw := new(bytes.Buffer)
+	put := func(v uint64) {
+		buf := make([]byte, binary.MaxVarintLen64)
+		raw.Write(buf[:binary.PutUvarint(buf, v)])
+	}
+	put(uint64(len(entries)))
+	var last uint64
+	for _, e := range entries {
+		put(e.TileID - last)
+		last = e.TileID
+	}
+	for _, e := range entries {
+		put(uint64(e.RunLength))
+	}
+	for _, e := range entries {
+		put(uint64(e.Length))
+	}
+	for _, e := range entries {
+		put(e.Offset + 1)
+	}
+
+	zipped := new(bytes.Buffer)
+	zw := gzip.NewWriter(zipped)
+	_, err := zw.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return zipped.Bytes()
+}
+
+// buildPMTiles writes an archive holding a white world tile and one red
+// tile at z1/1/0, reached through a leaf directory.
+func buildPMTiles(t *testing.T) []byte {
+	white, red := pngTile(t, color.White), pngTile(t, color.RGBA{R: 255, A: 255})
+	data := append(append([]byte{}, white...), red...)
+
+	redID := pmtilesTileID(1, 1, 0)
+	leaf := encodePMTilesDirectory(t, []pmtilesEntry{
+		{TileID: redID, Offset: uint64(len(white)), Length: uint32(len(red)), RunLength: 1},
+	})
+	root := encodePMTilesDirectory(t, []pmtilesEntry{
+		{TileID: 0, Offset: 0, Length: uint32(len(white)), RunLength: 1},
+		{TileID: 1, Offset: 0, Length: uint32(len(leaf)), RunLength: 0},
+	})
+
+	header := make([]byte, pmtilesHeaderLength)
+	copy(header, "PMTiles")
+	header[7] = 3
+	le := binary.LittleEndian
+	offset := uint64(pmtilesHeaderLength)
+	le.PutUint64(header[8:], offset)
+	le.PutUint64(header[16:], uint64(len(root)))
+	offset += uint64(len(root))
+	le.PutUint64(header[40:], offset)
+	le.PutUint64(header[48:], uint64(len(leaf)))
+	offset += uint64(len(leaf))
+	le.PutUint64(header[56:], offset)
+	le.PutUint64(header[64:], uint64(len(data)))
+	header[97] = pmtilesCompressionGzip
+	header[98] = pmtilesCompressionNone
+	header[99] = pmtilesTilePNG
+	header[101] = 1
+
+	archive := append(header, root...)
+	archive = append(archive, leaf...)
+	return append(archive, data...)
+}
+
+func TestPMTilesTileID(t *testing.T) {
+	assert.Equal(t, uint64(0), pmtilesTileID(0, 0, 0))
+	assert.Equal(t, uint64(1), pmtilesTileID(1, 0, 0))
+	assert.Equal(t, uint64(2), pmtilesTileID(1, 0, 1))
+	assert.Equal(t, uint64(3), pmtilesTileID(1, 1, 1))
+	assert.Equal(t, uint64(4), pmtilesTileID(1, 1, 0))
+	assert.Equal(t, uint64(5), pmtilesTileID(2, 0, 0))
+	assert.Equal(t, uint64(20), pmtilesTileID(2, 3, 0))
+}
+
+func TestPMTiles(t *testing.T) {
+	p, err := NewPMTiles(bytes.NewReader(buildPMTiles(t)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.MaxZoom)
+
+	img, err := p.Tile(geo.NewSlippyMapTile(0, 0, 0))
+	require.NoError(t, err)
+	require.NotNil(t, img)
+	assert.Equal(t, color.NRGBAModel.Convert(color.White), color.NRGBAModel.Convert(img.At(0, 0)))
+
+	img, err = p.Tile(geo.NewSlippyMapTile(1, 0, 1))
+	require.NoError(t, err)
+	require.NotNil(t, img, "tiles in leaf directories are found")
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, color.NRGBAModel.Convert(img.At(0, 0)))
+
+	img, err = p.Tile(geo.NewSlippyMapTile(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Nil(t, img, "missing tiles are transparent")
+}
+
+func TestPMTilesRejectsOtherFiles(t *testing.T) {
+	_, err := NewPMTiles(bytes.NewReader(make([]byte, pmtilesHeaderLength)))
+	assert.Error(t, err)
+}
+
+func TestLoadSourcesArchive(t *testing.T) {
+	path := filepath.Join(tempDir(t), "basemap.pmtiles")
+	require.NoError(t, ioutil.WriteFile(path, buildPMTiles(t), 0644))
+
+	sources, err := LoadSources(strings.NewReader(
+		`[{"type": "pmtiles", "name": "offline", "path": "` + path + `", "attribution": "© test"}]`))
+	require.NoError(t, err)
+	require.IsType(t, &PMTiles{}, sources["offline"])
+	p := sources["offline"].(*PMTiles)
+	assert.Equal(t, "offline", p.Name)
+	assert.Equal(t, "© test", p.Attribution)
+
+	_, err = LoadSources(strings.NewReader(`[{"type": "pmtiles", "name": "missing", "path": "/nonexistent.pmtiles"}]`))
+	assert.Error(t, err)
+	_, err = LoadSources(strings.NewReader(`[{"type": "mbtiles", "name": "offline", "path": "` + path + `"}]`))
+	assert.Error(t, err, "mbtiles needs the mosaic/mbtiles package")
+}
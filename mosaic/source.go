@@ -0,0 +1,474 @@
+package mosaic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // tile servers commonly serve JPEG imagery
+	_ "image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/pkg/errors"
+)
+
+// Source describes a tile service: where its tiles come from, the zoom
+// levels it serves, and the attribution its license requires.
+type Source struct {
+	Name        string `json:"name"`
+	MinZoom     int    `json:"min_zoom"`
+	MaxZoom     int    `json:"max_zoom"`
+	TileSize    int    `json:"tile_size"`
+	Attribution string `json:"attribution"`
+	// APIKey replaces {apikey} wherever it appears in the URL template.
+	APIKey string `json:"api_key"`
+	// Headers are sent with every request, for services that authenticate
+	// with a header rather than a query parameter.
+	Headers map[string]string `json:"headers"`
+	Client  *http.Client      `json:"-"`
+}
+
+func (s *Source) source() *Source {
+	return s
+}
+
+// InZoomRange reports whether the source has tiles at zoom level z.
+func (s *Source) InZoomRange(z int) bool {
+	return z >= s.MinZoom && (s.MaxZoom == 0 || z <= s.MaxZoom)
+}
+
+// MaxConcurrentPerHost bounds how many tile requests are in flight to any
+// one host at once, across every Source and TileCache in the process. The
+// OpenStreetMap tile usage policy allows each client two connections.
+var MaxConcurrentPerHost = 2
+
+var hostSlots = struct {
+	sync.Mutex
+	hosts map[string]chan struct{}
+}{hosts: map[string]chan struct{}{}}
+
+func slotsFor(host string) chan struct{} {
+	hostSlots.Lock()
+	defer hostSlots.Unlock()
+	slots, ok := hostSlots.hosts[host]
+	if !ok {
+		n := MaxConcurrentPerHost
+		if n < 1 {
+			n = 1
+		}
+		slots = make(chan struct{}, n)
+		hostSlots.hosts[host] = slots
+	}
+	return slots
+}
+
+type holdingSlot struct{}
+
+// holdSlot waits for one of the slots for req's host and returns req marked
+// as holding it, so that a TileCache it passes through doesn't wait for a
+// second one, and a function that frees it.
+func holdSlot(req *http.Request) (*http.Request, func()) {
+	if req.Context().Value(holdingSlot{}) != nil {
+		return req, func() {}
+	}
+	slots := slotsFor(req.URL.Host)
+	slots <- struct{}{}
+	return req.WithContext(context.WithValue(req.Context(), holdingSlot{}, true)), func() { <-slots }
+}
+
+// fetch downloads and decodes a tile. Missing tiles, which many services
+// report with 404 or 204 for empty areas, are returned as nil.
+func (s *Source) fetch(rawurl string) (image.Image, error) {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build tile request")
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, release := holdSlot(req)
+	defer release()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get tile from %s", s.Name)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("got status code %d from %s", resp.StatusCode, s.Name)
+	}
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode tile from %s", s.Name)
+	}
+	return img, nil
+}
+
+var _ TileSource = &XYZSource{}
+
+// XYZSource fetches tiles from a URL template in the style used by
+// OpenStreetMap and most web maps. The template may contain
+//
+//	{s}      one of Subdomains, chosen at random
+//	{z} {x} {y}
+//	{-y}     the row in the TMS scheme, counted from the bottom
+//	{q}      the Bing Maps quadkey
+//	{apikey} the Source's APIKey
+//	{time}   the time of the tile, for layers that change over time
+type XYZSource struct {
+	Source
+	URLTemplate string   `json:"url"`
+	Subdomains  []string `json:"subdomains"`
+	// TimeFormat formats {time} in UTC, defaulting to RFC 3339.
+	TimeFormat string `json:"time_format"`
+	// CurrentTime replaces {time} when no time is given, e.g. "now".
+	CurrentTime string `json:"current_time"`
+}
+
+// URL returns the address of tile.
+func (s *XYZSource) URL(tile *geo.SlippyMapTile) string {
+	return s.URLAt(tile, time.Time{})
+}
+
+// URLAt returns the address of tile at time t, or at CurrentTime if t is
+// zero.
+func (s *XYZSource) URLAt(tile *geo.SlippyMapTile, t time.Time) string {
+	when := s.CurrentTime
+	if !t.IsZero() {
+		format := s.TimeFormat
+		if format == "" {
+			format = time.RFC3339
+		}
+		when = t.UTC().Format(format)
+	}
+	subdomain := ""
+	if len(s.Subdomains) > 0 {
+		subdomain = s.Subdomains[rand.Intn(len(s.Subdomains))]
+	}
+	return strings.NewReplacer(
+		"{s}", subdomain,
+		"{z}", strconv.Itoa(tile.Z),
+		"{x}", strconv.Itoa(tile.X),
+		"{y}", strconv.Itoa(tile.Y),
+		"{-y}", strconv.Itoa(tile.TMSY()),
+		"{q}", tile.Quadkey(),
+		"{apikey}", s.APIKey,
+		"{time}", when,
+	).Replace(s.URLTemplate)
+}
+
+// Tile fetches tile, returning nil outside the source's zoom range.
+func (s *XYZSource) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	return s.TileAt(tile, time.Time{})
+}
+
+// TileAt fetches tile at time t.
+func (s *XYZSource) TileAt(tile *geo.SlippyMapTile, t time.Time) (image.Image, error) {
+	if !s.InZoomRange(tile.Z) {
+		return nil, nil
+	}
+	return s.fetch(s.URLAt(tile, t))
+}
+
+var _ TileSource = &WMTSSource{}
+
+// WMTSSource fetches tiles from an OGC Web Map Tile Service using a tile
+// matrix set aligned with the slippy map grid, such as GoogleMapsCompatible.
+// When URLTemplate is set the RESTful encoding is used, with {TileMatrix},
+// {TileRow}, {TileCol}, {Layer}, {Style}, {TileMatrixSet} and {apikey}
+// placeholders; otherwise GetTile requests are sent to BaseURL.
+type WMTSSource struct {
+	Source
+	BaseURL       string `json:"base_url"`
+	URLTemplate   string `json:"url"`
+	Layer         string `json:"layer"`
+	Style         string `json:"style"`
+	TileMatrixSet string `json:"tile_matrix_set"`
+	// TileMatrixPrefix is prepended to the zoom level to name the tile
+	// matrix, for sets whose matrices are named like "EPSG:3857:7".
+	TileMatrixPrefix string `json:"tile_matrix_prefix"`
+	Format           string `json:"format"`
+}
+
+// URL returns the address of tile.
+func (s *WMTSSource) URL(tile *geo.SlippyMapTile) string {
+	matrix := s.TileMatrixPrefix + strconv.Itoa(tile.Z)
+	style := s.Style
+	if style == "" {
+		style = "default"
+	}
+	if s.URLTemplate != "" {
+		return strings.NewReplacer(
+			"{TileMatrix}", matrix,
+			"{TileRow}", strconv.Itoa(tile.Y),
+			"{TileCol}", strconv.Itoa(tile.X),
+			"{Layer}", s.Layer,
+			"{Style}", style,
+			"{TileMatrixSet}", s.TileMatrixSet,
+			"{apikey}", s.APIKey,
+		).Replace(s.URLTemplate)
+	}
+	format := s.Format
+	if format == "" {
+		format = "image/png"
+	}
+	q := url.Values{}
+	q.Set("SERVICE", "WMTS")
+	q.Set("REQUEST", "GetTile")
+	q.Set("VERSION", "1.0.0")
+	q.Set("LAYER", s.Layer)
+	q.Set("STYLE", style)
+	q.Set("FORMAT", format)
+	q.Set("TILEMATRIXSET", s.TileMatrixSet)
+	q.Set("TILEMATRIX", matrix)
+	q.Set("TILEROW", strconv.Itoa(tile.Y))
+	q.Set("TILECOL", strconv.Itoa(tile.X))
+	return appendQuery(strings.Replace(s.BaseURL, "{apikey}", s.APIKey, -1), q)
+}
+
+// Tile fetches tile, returning nil outside the source's zoom range.
+func (s *WMTSSource) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	if !s.InZoomRange(tile.Z) {
+		return nil, nil
+	}
+	return s.fetch(s.URL(tile))
+}
+
+var _ TileSource = &WMSSource{}
+
+// WMSSource renders tiles with GetMap requests to an OGC Web Map Service,
+// asking for each tile's extent in Web Mercator (EPSG:3857).
+type WMSSource struct {
+	Source
+	BaseURL string `json:"base_url"`
+	Layers  string `json:"layers"`
+	Styles  string `json:"styles"`
+	Format  string `json:"format"`
+	// Version defaults to 1.3.0.
+	Version string `json:"version"`
+	// Params are added to every request, e.g. TIME for a time-enabled
+	// layer.
+	Params map[string]string `json:"params"`
+}
+
+// webMercatorExtent is the distance in meters from the origin to the edge of
+// the Web Mercator projection.
+const webMercatorExtent = 20037508.342789244
+
+// URL returns the GetMap request for tile.
+func (s *WMSSource) URL(tile *geo.SlippyMapTile) string {
+	return s.URLAt(tile, time.Time{})
+}
+
+// URLAt returns the GetMap request for tile at time t, sent as the TIME
+// parameter unless t is zero.
+func (s *WMSSource) URLAt(tile *geo.SlippyMapTile, t time.Time) string {
+	size := 2 * webMercatorExtent / float64(int(1)<<uint(tile.Z))
+	minX := -webMercatorExtent + float64(tile.X)*size
+	maxY := webMercatorExtent - float64(tile.Y)*size
+	tileSize := s.TileSize
+	if tileSize == 0 {
+		tileSize = geo.TileSize
+	}
+	version := s.Version
+	if version == "" {
+		version = "1.3.0"
+	}
+	format := s.Format
+	if format == "" {
+		format = "image/png"
+	}
+
+	q := url.Values{}
+	q.Set("SERVICE", "WMS")
+	q.Set("REQUEST", "GetMap")
+	q.Set("VERSION", version)
+	q.Set("LAYERS", s.Layers)
+	q.Set("STYLES", s.Styles)
+	q.Set("FORMAT", format)
+	q.Set("TRANSPARENT", "true")
+	q.Set("WIDTH", strconv.Itoa(tileSize))
+	q.Set("HEIGHT", strconv.Itoa(tileSize))
+	// 1.3.0 renamed SRS to CRS
+	if version == "1.1.1" {
+		q.Set("SRS", "EPSG:3857")
+	} else {
+		q.Set("CRS", "EPSG:3857")
+	}
+	q.Set("BBOX", fmt.Sprintf("%.6f,%.6f,%.6f,%.6f", minX, maxY-size, minX+size, maxY))
+	for k, v := range s.Params {
+		q.Set(k, v)
+	}
+	if !t.IsZero() {
+		q.Set("TIME", t.UTC().Format(time.RFC3339))
+	}
+	return appendQuery(strings.Replace(s.BaseURL, "{apikey}", s.APIKey, -1), q)
+}
+
+// Tile fetches tile, returning nil outside the source's zoom range.
+func (s *WMSSource) Tile(tile *geo.SlippyMapTile) (image.Image, error) {
+	return s.TileAt(tile, time.Time{})
+}
+
+// TileAt fetches tile at time t.
+func (s *WMSSource) TileAt(tile *geo.SlippyMapTile, t time.Time) (image.Image, error) {
+	if !s.InZoomRange(tile.Z) {
+		return nil, nil
+	}
+	return s.fetch(s.URLAt(tile, t))
+}
+
+func appendQuery(base string, q url.Values) string {
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + q.Encode()
+}
+
+// LoadSources reads a JSON array of source definitions, each with a type of
+// "xyz", "wmts" or "wms" and the fields of that source type, so that new
+// basemaps and overlays can be configured without code:
+//
+//	[{"type": "xyz", "name": "osm", "url": "https://tile.openstreetmap.org/{z}/{x}/{y}.png",
+//	  "max_zoom": 19, "attribution": "© OpenStreetMap contributors"}]
+//
+// Offline archives have a type of "pmtiles", or any type added with
+// RegisterArchive like the "mbtiles" of the mosaic/mbtiles package, and a
+// "path", and are opened as they are loaded.
+//
+// Sources are returned by name.
+func LoadSources(r io.Reader) (map[string]TileSource, error) {
+	raw := []json.RawMessage{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, "failed to decode tile source definitions")
+	}
+	sources := map[string]TileSource{}
+	for i, def := range raw {
+		kind := struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(def, &kind); err != nil {
+			return nil, errors.Wrapf(err, "invalid tile source definition %d", i)
+		}
+		if kind.Name == "" {
+			return nil, errors.Errorf("tile source definition %d has no name", i)
+		}
+		if open := archiveOpener(kind.Type); open != nil {
+			archive, err := openArchive(open, def)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to open tile archive for %s", kind.Name)
+			}
+			sources[kind.Name] = archive
+			continue
+		}
+		var source TileSource
+		switch strings.ToLower(kind.Type) {
+		case "xyz", "":
+			source = new(XYZSource)
+		case "wmts":
+			source = new(WMTSSource)
+		case "wms":
+			source = new(WMSSource)
+		default:
+			return nil, errors.Errorf("unknown tile source type '%s' for %s", kind.Type, kind.Name)
+		}
+		if err := json.Unmarshal(def, source); err != nil {
+			return nil, errors.Wrapf(err, "invalid tile source definition for %s", kind.Name)
+		}
+		sources[kind.Name] = source
+	}
+	return sources, nil
+}
+
+// ArchiveOpener opens the tile archive at path.
+type ArchiveOpener func(path string) (TileSource, error)
+
+var (
+	archivesMu sync.RWMutex
+	archives   = map[string]ArchiveOpener{
+		"pmtiles": func(path string) (TileSource, error) { return OpenPMTiles(path) },
+	}
+)
+
+// RegisterArchive lets LoadSources open archives with a type of kind,
+// replacing any opener already registered for it. Archives should embed
+// Source so that definitions can rename them.
+func RegisterArchive(kind string, open ArchiveOpener) {
+	archivesMu.Lock()
+	defer archivesMu.Unlock()
+	archives[strings.ToLower(kind)] = open
+}
+
+func archiveOpener(kind string) ArchiveOpener {
+	archivesMu.RLock()
+	defer archivesMu.RUnlock()
+	return archives[strings.ToLower(kind)]
+}
+
+// openArchive opens the archive named by def, letting def override the
+// archive's own name and attribution.
+func openArchive(open ArchiveOpener, def json.RawMessage) (TileSource, error) {
+	fields := struct {
+		Path        string `json:"path"`
+		Name        string `json:"name"`
+		Attribution string `json:"attribution"`
+	}{}
+	if err := json.Unmarshal(def, &fields); err != nil {
+		return nil, err
+	}
+	if fields.Path == "" {
+		return nil, errors.New("no path given")
+	}
+	archive, err := open(fields.Path)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := archive.(interface{ source() *Source }); ok {
+		s.source().Name = fields.Name
+		if fields.Attribution != "" {
+			s.source().Attribution = fields.Attribution
+		}
+	}
+	return archive, nil
+}
+
+// OpenStreetMap is the standard OpenStreetMap tile layer. Its tile usage
+// policy requires clients to cache tiles, so give it a TileCache's Client
+// before rendering many maps.
+var OpenStreetMap = &XYZSource{
+	Source: Source{
+		Name:        "OpenStreetMap",
+		MaxZoom:     19,
+		Attribution: "© OpenStreetMap contributors",
+	},
+	URLTemplate: "https://tile.openstreetmap.org/{z}/{x}/{y}.png",
+}
+
+// NowCOASTRadar is NOAA nowCOAST's NEXRAD base reflectivity mosaic over the
+// United States.
+var NowCOASTRadar = &WMSSource{
+	Source: Source{
+		Name:        "nowCOAST radar",
+		Attribution: "NOAA nowCOAST",
+	},
+	BaseURL: "https://nowcoast.noaa.gov/geoserver/observations/weather_radar/ows",
+	Layers:  "base_reflectivity_mosaic",
+}
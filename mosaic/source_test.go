@@ -0,0 +1,145 @@
+package mosaic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	geo "github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXYZSourceURL(t *testing.T) {
+	s := &XYZSource{
+		Source:      Source{APIKey: "secret"},
+		URLTemplate: "https://{s}.example.com/{z}/{x}/{y}/{-y}/{q}.png?key={apikey}",
+		Subdomains:  []string{"a"},
+	}
+	assert.Equal(t, "https://a.example.com/3/3/5/2/213.png?key=secret",
+		s.URL(geo.NewSlippyMapTile(3, 5, 3)))
+}
+
+func TestXYZSourceURLAt(t *testing.T) {
+	s := &XYZSource{
+		URLTemplate: "https://example.com/radar/{time}/{z}/{x}/{y}.png",
+		CurrentTime: "now",
+	}
+	tile := geo.NewSlippyMapTile(3, 5, 3)
+	assert.Equal(t, "https://example.com/radar/now/3/3/5.png", s.URL(tile))
+	when := time.Date(2020, 8, 1, 7, 30, 0, 0, time.FixedZone("CDT", -5*60*60))
+	assert.Equal(t, "https://example.com/radar/2020-08-01T12:30:00Z/3/3/5.png", s.URLAt(tile, when))
+}
+
+func TestWMTSSourceURL(t *testing.T) {
+	s := &WMTSSource{
+		BaseURL:          "https://example.com/wmts",
+		Layer:            "radar",
+		TileMatrixSet:    "EPSG:3857",
+		TileMatrixPrefix: "EPSG:3857:",
+	}
+	u, err := url.Parse(s.URL(geo.NewSlippyMapTile(3, 5, 3)))
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "GetTile", q.Get("REQUEST"))
+	assert.Equal(t, "radar", q.Get("LAYER"))
+	assert.Equal(t, "default", q.Get("STYLE"))
+	assert.Equal(t, "EPSG:3857:3", q.Get("TILEMATRIX"))
+	assert.Equal(t, "5", q.Get("TILEROW"))
+	assert.Equal(t, "3", q.Get("TILECOL"))
+
+	s.URLTemplate = "https://example.com/{Layer}/{Style}/{TileMatrixSet}/{TileMatrix}/{TileRow}/{TileCol}.png"
+	assert.Equal(t, "https://example.com/radar/default/EPSG:3857/EPSG:3857:3/5/3.png",
+		s.URL(geo.NewSlippyMapTile(3, 5, 3)))
+}
+
+func TestWMSSourceURL(t *testing.T) {
+	s := &WMSSource{
+		BaseURL: "https://example.com/wms?map=radar",
+		Layers:  "reflectivity",
+		Params:  map[string]string{"TIME": "2020-07-04T12:00:00Z"},
+	}
+	u, err := url.Parse(s.URL(geo.NewSlippyMapTile(0, 0, 1)))
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "radar", q.Get("map"))
+	assert.Equal(t, "GetMap", q.Get("REQUEST"))
+	assert.Equal(t, "EPSG:3857", q.Get("CRS"))
+	assert.Equal(t, "-20037508.342789,0.000000,0.000000,20037508.342789", q.Get("BBOX"))
+	assert.Equal(t, "256", q.Get("WIDTH"))
+	assert.Equal(t, "2020-07-04T12:00:00Z", q.Get("TIME"))
+
+	s.Version = "1.1.1"
+	u, err = url.Parse(s.URL(geo.NewSlippyMapTile(0, 0, 1)))
+	require.NoError(t, err)
+	assert.Equal(t, "EPSG:3857", u.Query().Get("SRS"))
+}
+
+func TestSourceFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if strings.HasPrefix(r.URL.Path, "/2/") {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/3/") {
+			http.Error(w, "nope", http.StatusForbidden)
+			return
+		}
+		png.Encode(w, imaging.New(256, 256, color.White))
+	}))
+	defer server.Close()
+
+	s := &XYZSource{
+		Source: Source{
+			Name:    "test",
+			MaxZoom: 3,
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+		URLTemplate: server.URL + "/{z}/{x}/{y}.png",
+	}
+	img, err := s.Tile(geo.NewSlippyMapTile(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+
+	img, err = s.Tile(geo.NewSlippyMapTile(0, 0, 2))
+	assert.NoError(t, err)
+	assert.Nil(t, img, "missing tiles are transparent")
+
+	_, err = s.Tile(geo.NewSlippyMapTile(0, 0, 3))
+	assert.Error(t, err)
+
+	img, err = s.Tile(geo.NewSlippyMapTile(0, 0, 4))
+	assert.NoError(t, err)
+	assert.Nil(t, img, "tiles beyond MaxZoom are transparent")
+}
+
+func TestLoadSources(t *testing.T) {
+	sources, err := LoadSources(bytes.NewBufferString(`[
+		{"type": "xyz", "name": "osm", "url": "https://tile.openstreetmap.org/{z}/{x}/{y}.png",
+		 "max_zoom": 19, "attribution": "© OpenStreetMap contributors"},
+		{"type": "wms", "name": "radar", "base_url": "https://example.com/wms", "layers": "1"},
+		{"type": "wmts", "name": "sat", "base_url": "https://example.com/wmts", "layer": "sat",
+		 "headers": {"X-Key": "secret"}}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, sources, 3)
+	osm := sources["osm"].(*XYZSource)
+	assert.Equal(t, 19, osm.MaxZoom)
+	assert.Equal(t, "© OpenStreetMap contributors", osm.Attribution)
+	assert.Equal(t, "1", sources["radar"].(*WMSSource).Layers)
+	assert.Equal(t, "secret", sources["sat"].(*WMTSSource).Headers["X-Key"])
+
+	_, err = LoadSources(bytes.NewBufferString(`[{"type": "tms", "name": "x"}]`))
+	assert.Error(t, err)
+	_, err = LoadSources(bytes.NewBufferString(`[{"type": "xyz"}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package nws
+
+import (
+	"fmt"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/render"
+)
+
+// Render returns the observation and alerts for any of the render
+// package's renderers.
+func (o *Result) Render() *render.Weather {
+	r := &render.Observation{
+		Location:    o.Name,
+		Station:     o.Station,
+		Conditions:  conditions.FromNWS(o.Icon, o.Conditions),
+		Description: o.Conditions,
+		Fields: []render.Field{
+			{Label: "Temperature", Value: o.Temperature + " °F"},
+			{Label: "Relative Humidity", Value: o.RelativeHumidity + "%"},
+			{Label: "Heat Index", Value: o.HeatIndex + " °F"},
+			{Label: "Barometric Pressure", Value: fmt.Sprintf("%.2f in Hg", o.BarometricPressure)},
+			{Label: "Wind Speed", Value: fmt.Sprintf("%.1f m/s", o.WindSpeed)},
+			{Label: "Wind Gust", Value: fmt.Sprintf("%.1f m/s", o.WindGust)},
+			{Label: "Precipitation in the Last Hour", Value: fmt.Sprintf("%.1f m", o.PrecipitationLastHour)},
+		},
+	}
+	// Timestamp has no year once localTimestamp has rewritten it, so it's
+	// shown as is
+	if o.Timestamp != "" {
+		r.Fields = append(r.Fields, render.Field{Label: "Time of Observation", Value: o.Timestamp})
+	}
+
+	w := &render.Weather{Observation: r}
+	for _, a := range o.Alerts {
+		w.Alerts = append(w.Alerts, &render.Alert{
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Headline:    a.Headline,
+			Description: a.Description,
+			Instruction: a.Instruction,
+			Sender:      a.Sender,
+		})
+	}
+	return w
+}
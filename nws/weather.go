@@ -10,14 +10,20 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/geocoding"
 )
 
 type zipCode string
 type LatLong [2]float64
 type Result struct {
-	BarometricPressure    float32
-	Conditions            string
-	HeatIndex             string
+	BarometricPressure float32
+	Conditions         string
+	HeatIndex          string
+	// Icon is the URL of the NWS icon for the conditions.
+	Icon                  string
 	Name                  string
 	PrecipitationLastHour float32
 	RelativeHumidity      string
@@ -87,6 +93,11 @@ type Observation struct {
 	ObservationProperties `json:"properties"`
 }
 
+// Report returns the provider independent condition, at day or night.
+func (o *ObservationProperties) Report() conditions.Report {
+	return conditions.FromNWS(o.Icon, o.TextDescription)
+}
+
 type NWSRequest struct {
 	Client  *http.Client
 	Request *http.Request
@@ -113,10 +124,27 @@ func toFahrenheit(in float32) string {
 	return fmt.Sprintf("%.1f", in*1.8+32)
 }
 
+// localTimestamp rewrites an ISO 8601 timestamp from the API in the time
+// zone at l, leaving it as is if it can't be parsed.
+func localTimestamp(timestamp string, l LatLong) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	c := &geocoding.Coordinates{Latitude: l[0], Longitude: l[1]}
+	return c.LocalTime(t).Format("Mon Jan 2 3:04 PM MST")
+}
+
 func toInchesHg(pascals float32) float32 {
 	return pascals / 3386.38866
 }
 
+// LookupZip returns the coordinates of a five digit US ZIP code from the
+// bundled ZIP code table.
+func LookupZip(zip string) (LatLong, error) {
+	return ZipToLatLong(zipCode(zip))
+}
+
 func ZipToLatLong(z zipCode) (LatLong, error) {
 	if l, ok := zipMap[z]; ok {
 		return l, nil
@@ -207,24 +235,54 @@ type AlertList struct {
 }
 
 type StationProperties struct {
-	County string `json:"county"`
+	StationIdentifier string `json:"stationIdentifier"`
+	Name              string `json:"name"`
+	TimeZone          string `json:"timeZone"`
+	County            string `json:"county"`
+}
+
+type StationGeometry struct {
+	Coordinates []float64 `json:"coordinates"`
 }
 
 type Station struct {
+	Geometry          StationGeometry `json:"geometry"`
 	StationProperties `json:"properties"`
 }
 
-func getCountyCode(stationID string) string {
+// LatLong returns the station's location; the API orders the GeoJSON point
+// longitude first.
+func (s *Station) LatLong() (LatLong, error) {
+	if len(s.Geometry.Coordinates) < 2 {
+		return LatLong{}, fmt.Errorf("station %s has no location", s.StationIdentifier)
+	}
+	return LatLong{s.Geometry.Coordinates[1], s.Geometry.Coordinates[0]}, nil
+}
+
+// GetStation fetches the metadata for an observation station, such as an
+// airport's ICAO identifier.
+func GetStation(stationID string) (*Station, error) {
 	n := NewRequest(fmt.Sprintf(
 		"/stations/%s", stationID))
 	resp, err := n.Do()
-	defer resp.Body.Close()
 	if err != nil {
-		return ""
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Bad response from NWS for station %s: %s", stationID, resp.Status)
 	}
 	s := new(Station)
 	decoder := json.NewDecoder(resp.Body)
 	if err = decoder.Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func getCountyCode(stationID string) string {
+	s, err := GetStation(stationID)
+	if err != nil {
 		return ""
 	}
 
@@ -337,13 +395,19 @@ func GetWeather(zip string) (*Result, error) {
 		return nil, err
 	}
 
+	timestamp := o.Timestamp
+	if l, err := ZipToLatLong(zipCode(zip)); err == nil {
+		timestamp = localTimestamp(o.Timestamp, l)
+	}
+
 	windChill := fmt.Sprintf("%.2f °%s", o.WindChill.Value, o.WindChill.UnitCode)
 
 	return &Result{
 		Name:                  zip,
 		Station:               stationName,
 		Conditions:            o.TextDescription,
-		Timestamp:             o.Timestamp,
+		Icon:                  o.Icon,
+		Timestamp:             timestamp,
 		Temperature:           toFahrenheit(o.Temperature.Value),
 		BarometricPressure:    toInchesHg(o.BarometricPressure.Value),
 		WindSpeed:             o.WindSpeed.Value,
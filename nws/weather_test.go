@@ -14,3 +14,10 @@ func TestWeather(t *testing.T) {
 	assert.NotEqual("", r.String())
 	t.Logf("%s", r.String())
 }
+
+func TestLocalTimestamp(t *testing.T) {
+	l, err := LookupZip("78703")
+	assert.NoError(t, err)
+	assert.Equal(t, "Sat Sep 2 4:51 PM CDT", localTimestamp("2017-09-02T21:51:00+00:00", l))
+	assert.Equal(t, "not a time", localTimestamp("not a time", l))
+}
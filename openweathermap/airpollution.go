@@ -0,0 +1,96 @@
+package openweathermap
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gigawhitlocks/weather/geocoding"
+)
+
+// AQI is OpenWeatherMap's air quality index, from 1 (good) to 5 (very
+// poor).
+type AQI int
+
+const (
+	Good AQI = 1 + iota
+	Fair
+	Moderate
+	Poor
+	VeryPoor
+)
+
+func (a AQI) String() string {
+	switch a {
+	case Good:
+		return "Good"
+	case Fair:
+		return "Fair"
+	case Moderate:
+		return "Moderate"
+	case Poor:
+		return "Poor"
+	case VeryPoor:
+		return "Very Poor"
+	}
+	return fmt.Sprintf("AQI(%d)", int(a))
+}
+
+// Pollutants are concentrations in μg/m³.
+type Pollutants struct {
+	CO   float64 `json:"co"`
+	NO   float64 `json:"no"`
+	NO2  float64 `json:"no2"`
+	O3   float64 `json:"o3"`
+	SO2  float64 `json:"so2"`
+	PM25 float64 `json:"pm2_5"`
+	PM10 float64 `json:"pm10"`
+	NH3  float64 `json:"nh3"`
+}
+
+// AirQuality is the air quality at a point in time.
+type AirQuality struct {
+	Time Time `json:"dt"`
+	Main struct {
+		AQI AQI `json:"aqi"`
+	} `json:"main"`
+	Components Pollutants `json:"components"`
+}
+
+// AirPollution is the response of the Air Pollution API.
+type AirPollution struct {
+	Coord Coordinates  `json:"coord"`
+	List  []AirQuality `json:"list"`
+}
+
+// GetAirPollution returns the current air quality at coords.
+func (c *Client) GetAirPollution(coords *geocoding.Coordinates) (*AirPollution, error) {
+	a := new(AirPollution)
+	if err := c.get("/air_pollution", coordinateParams(coords), a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAirPollutionForecast returns the hourly air quality forecast at
+// coords for the next few days.
+func (c *Client) GetAirPollutionForecast(coords *geocoding.Coordinates) (*AirPollution, error) {
+	a := new(AirPollution)
+	if err := c.get("/air_pollution/forecast", coordinateParams(coords), a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAirPollutionHistory returns the hourly air quality at coords from
+// start to end.
+func (c *Client) GetAirPollutionHistory(coords *geocoding.Coordinates, start, end time.Time) (*AirPollution, error) {
+	params := coordinateParams(coords)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	a := new(AirPollution)
+	if err := c.get("/air_pollution/history", params, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,76 @@
+package openweathermap
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Default OpenWeatherMap endpoints.
+const (
+	APIURL       = "https://api.openweathermap.org/data/2.5"
+	OneCallURL   = "https://api.openweathermap.org/data/3.0"
+	GeoURL       = "https://api.openweathermap.org/geo/1.0"
+	TileURL      = "https://tile.openweathermap.org/map"
+	MapsURL      = "https://maps.openweathermap.org/maps/2.0"
+	SatelliteURL = "https://sat.owm.io"
+)
+
+// Client makes requests to OpenWeatherMap with APIKey. The base URLs can
+// be pointed elsewhere, e.g. at a test server.
+type Client struct {
+	APIKey       string
+	APIURL       string
+	OneCallURL   string
+	GeoURL       string
+	TileURL      string
+	MapsURL      string
+	SatelliteURL string
+	HTTPClient   *http.Client
+	// Logger, when set, logs each API request with the key redacted.
+	Logger *log.Logger
+}
+
+// NewClient returns a client for apiKey using the default endpoints.
+func NewClient(apiKey string) *Client {
+	return &Client{
+		APIKey:       apiKey,
+		APIURL:       APIURL,
+		OneCallURL:   OneCallURL,
+		GeoURL:       GeoURL,
+		TileURL:      TileURL,
+		MapsURL:      MapsURL,
+		SatelliteURL: SatelliteURL,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
+}
+
+// redact replaces API keys in rawurl so it can be logged.
+func redact(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	q := u.Query()
+	for _, k := range []string{"appid", "APPID"} {
+		if q.Get(k) != "" {
+			q.Set(k, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
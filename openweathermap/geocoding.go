@@ -0,0 +1,117 @@
+package openweathermap
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gigawhitlocks/weather/geocoding"
+)
+
+// Place is a result from the Geocoding API.
+type Place struct {
+	Name       string            `json:"name"`
+	LocalNames map[string]string `json:"local_names"`
+	Lat        float64           `json:"lat"`
+	Lon        float64           `json:"lon"`
+	Country    string            `json:"country"`
+	State      string            `json:"state"`
+	Zip        string            `json:"zip"`
+}
+
+// LatLong converts p's location for use with the rest of the library.
+func (p *Place) LatLong() *geocoding.Coordinates {
+	return &geocoding.Coordinates{Latitude: p.Lat, Longitude: p.Lon}
+}
+
+// String formats p as "Name, State, Country", leaving out what's missing.
+func (p *Place) String() string {
+	parts := []string{}
+	for _, part := range []string{p.Name, p.State, p.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Geocode returns up to limit places matching query, a city name
+// optionally followed by a state code and country code, e.g.
+// "Austin,TX,US". A US ZIP code returns its single place.
+func (c *Client) Geocode(query string, limit int) ([]*Place, error) {
+	query = strings.TrimSpace(query)
+	if ZipPattern.MatchString(query) && len(query) == 5 {
+		place, err := c.GeocodeZip(query, "us")
+		if err != nil {
+			return nil, err
+		}
+		return []*Place{place}, nil
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+	places := []*Place{}
+	if err := c.getFrom(c.GeoURL, "/direct", params, &places); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// GeocodeZip returns the place for a postal code in country, an ISO 3166
+// country code.
+func (c *Client) GeocodeZip(zip, country string) (*Place, error) {
+	params := url.Values{}
+	params.Set("zip", zip+","+country)
+	place := new(Place)
+	if err := c.getFrom(c.GeoURL, "/zip", params, place); err != nil {
+		return nil, err
+	}
+	return place, nil
+}
+
+// ReverseGeocode returns up to limit places near coords.
+func (c *Client) ReverseGeocode(coords *geocoding.Coordinates, limit int) ([]*Place, error) {
+	params := coordinateParams(coords)
+	params.Set("limit", strconv.Itoa(limit))
+	places := []*Place{}
+	if err := c.getFrom(c.GeoURL, "/reverse", params, &places); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+var _ geocoding.Geocoder = &Geocoder{}
+
+// Geocoder adapts the Geocoding API to geocoding.Geocoder.
+type Geocoder struct {
+	Client *Client
+	*Place
+}
+
+// Geocode looks up location, keeping the best match.
+func (g *Geocoder) Geocode(location string) error {
+	places, err := g.Client.Geocode(location, 1)
+	if err != nil {
+		return err
+	}
+	if len(places) == 0 {
+		return fmt.Errorf("no results for '%s'", location)
+	}
+	g.Place = places[0]
+	return nil
+}
+
+func (g *Geocoder) Latlong() *geocoding.Coordinates {
+	if g.Place == nil {
+		return nil
+	}
+	return g.Place.LatLong()
+}
+
+func (g *Geocoder) ParsedLocation() string {
+	if g.Place == nil {
+		return ""
+	}
+	return g.Place.String()
+}
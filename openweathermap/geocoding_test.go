@@ -0,0 +1,88 @@
+package openweathermap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c := NewClient("key")
+	c.APIURL = server.URL + "/data/2.5"
+	c.OneCallURL = server.URL + "/data/3.0"
+	c.GeoURL = server.URL + "/geo/1.0"
+	return c
+}
+
+func TestGeocode(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/geo/1.0/direct":
+			assert.Equal(t, "Austin,TX,US", q.Get("q"))
+			w.Write([]byte(`[{"name": "Austin", "lat": 30.2711, "lon": -97.7437, "country": "US", "state": "Texas"}]`))
+		case "/geo/1.0/zip":
+			assert.Equal(t, "78701,us", q.Get("zip"))
+			w.Write([]byte(`{"zip": "78701", "name": "Austin", "lat": 30.2713, "lon": -97.7426, "country": "US"}`))
+		case "/geo/1.0/reverse":
+			assert.Equal(t, "30.2711", q.Get("lat"))
+			w.Write([]byte(`[{"name": "Austin", "lat": 30.2711, "lon": -97.7437, "country": "US", "state": "Texas"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	places, err := c.Geocode("Austin,TX,US", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Equal(t, "Austin, Texas, US", places[0].String())
+
+	places, err = c.Geocode("78701", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "78701", places[0].Zip)
+
+	places, err = c.ReverseGeocode(&geocoding.Coordinates{Latitude: 30.2711, Longitude: -97.7437}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Austin", places[0].Name)
+
+	g := &Geocoder{Client: c}
+	require.NoError(t, g.Geocode("Austin,TX,US"))
+	assert.Equal(t, "Austin, Texas, US", g.ParsedLocation())
+	assert.InDelta(t, -97.7437, g.Latlong().Longitude, 1e-9)
+
+	l, err := c.GetTileNumbers("Austin,TX,US")
+	require.NoError(t, err)
+	assert.InDelta(t, 30.2711, l.Lat, 1e-9)
+}
+
+func TestAirPollution(t *testing.T) {
+	start := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/data/2.5/air_pollution/history" {
+			assert.Equal(t, "1596240000", r.URL.Query().Get("start"))
+		}
+		w.Write([]byte(`{"coord": {"lon": -97.74, "lat": 30.27}, "list": [{"dt": 1596240000,
+			"main": {"aqi": 2}, "components": {"co": 201.94, "no2": 0.77, "o3": 68.66, "pm2_5": 0.5, "pm10": 0.54}}]}`))
+	})
+	coords := &geocoding.Coordinates{Latitude: 30.27, Longitude: -97.74}
+
+	a, err := c.GetAirPollution(coords)
+	require.NoError(t, err)
+	require.Len(t, a.List, 1)
+	assert.Equal(t, Fair, a.List[0].Main.AQI)
+	assert.Equal(t, "Fair", a.List[0].Main.AQI.String())
+	assert.Equal(t, .5, a.List[0].Components.PM25)
+	assert.Equal(t, start, a.List[0].Time.Time)
+
+	_, err = c.GetAirPollutionForecast(coords)
+	require.NoError(t, err)
+	_, err = c.GetAirPollutionHistory(coords, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+}
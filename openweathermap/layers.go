@@ -0,0 +1,124 @@
+package openweathermap
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/gigawhitlocks/weather/mosaic"
+)
+
+// layer describes an OWM weather map layer: its 1.0 layer name or 2.0
+// operation code and the legend for its default palette.
+type layer struct {
+	name    string
+	v1      string
+	v2      string
+	opacity float64
+	legend  *mosaic.Legend
+}
+
+var temperatureLegend = &mosaic.Legend{Title: "Temperature (°C)", Stops: []mosaic.LegendStop{
+	{Label: "-40", Color: color.NRGBA{130, 22, 146, 255}},
+	{Label: "-20", Color: color.NRGBA{32, 140, 236, 255}},
+	{Label: "0", Color: color.NRGBA{35, 221, 221, 255}},
+	{Label: "10", Color: color.NRGBA{194, 255, 40, 255}},
+	{Label: "20", Color: color.NRGBA{255, 240, 40, 255}},
+	{Label: "30", Color: color.NRGBA{252, 128, 20, 255}},
+}}
+
+var precipitationLegend = &mosaic.Legend{Title: "Precipitation (mm/h)", Stops: []mosaic.LegendStop{
+	{Label: "0.5", Color: color.NRGBA{120, 120, 190, 255}},
+	{Label: "1", Color: color.NRGBA{110, 110, 205, 255}},
+	{Label: "10", Color: color.NRGBA{80, 80, 225, 255}},
+	{Label: "140", Color: color.NRGBA{20, 20, 255, 255}},
+}}
+
+var cloudsLegend = &mosaic.Legend{Title: "Clouds (%)", Stops: []mosaic.LegendStop{
+	{Label: "25", Color: color.NRGBA{250, 250, 255, 255}},
+	{Label: "50", Color: color.NRGBA{247, 247, 255, 255}},
+	{Label: "100", Color: color.NRGBA{240, 240, 255, 255}},
+}}
+
+var pressureLegend = &mosaic.Legend{Title: "Pressure (hPa)", Stops: []mosaic.LegendStop{
+	{Label: "960", Color: color.NRGBA{0, 170, 255, 255}},
+	{Label: "1000", Color: color.NRGBA{141, 231, 199, 255}},
+	{Label: "1010", Color: color.NRGBA{176, 247, 32, 255}},
+	{Label: "1020", Color: color.NRGBA{240, 184, 0, 255}},
+	{Label: "1040", Color: color.NRGBA{251, 85, 21, 255}},
+	{Label: "1080", Color: color.NRGBA{198, 0, 0, 255}},
+}}
+
+var windLegend = &mosaic.Legend{Title: "Wind (m/s)", Stops: []mosaic.LegendStop{
+	{Label: "5", Color: color.NRGBA{238, 206, 206, 255}},
+	{Label: "15", Color: color.NRGBA{179, 100, 188, 255}},
+	{Label: "25", Color: color.NRGBA{63, 33, 59, 255}},
+	{Label: "50", Color: color.NRGBA{116, 76, 172, 255}},
+	{Label: "100", Color: color.NRGBA{70, 0, 175, 255}},
+}}
+
+var layers = map[Map]*layer{
+	Clouds:                   {name: "clouds", v1: "clouds_new", opacity: .8, legend: cloudsLegend},
+	Precipitation:            {name: "precipitation", v1: "precipitation_new", opacity: .9, legend: precipitationLegend},
+	Temperature:              {name: "temp", v1: "temp_new", opacity: .6, legend: temperatureLegend},
+	Wind:                     {name: "wind", v1: "wind_new", opacity: .7, legend: windLegend},
+	Pressure:                 {name: "pressure", v1: "pressure_new", opacity: .6, legend: pressureLegend},
+	PrecipitationIntensity:   {name: "precipitation_intensity", v2: "PR0", opacity: .9, legend: precipitationLegend},
+	ConvectivePrecipitation:  {name: "convective_precipitation", v2: "PAC0", opacity: .9},
+	AccumulatedPrecipitation: {name: "accumulated_precipitation", v2: "PA0", opacity: .9},
+	AccumulatedRain:          {name: "accumulated_rain", v2: "PAR0", opacity: .9},
+	AccumulatedSnow:          {name: "accumulated_snow", v2: "PAS0", opacity: .9},
+	SnowDepth:                {name: "snow_depth", v2: "SD0", opacity: .8},
+	WindSpeed:                {name: "wind_speed", v2: "WS10", opacity: .7, legend: windLegend},
+	WindDirection:            {name: "wind_direction", v2: "WND", opacity: .8},
+	SeaLevelPressure:         {name: "sea_level_pressure", v2: "APM", opacity: .6, legend: pressureLegend},
+	AirTemperature:           {name: "air_temperature", v2: "TA2", opacity: .6, legend: temperatureLegend},
+	DewPoint:                 {name: "dew_point", v2: "TD2", opacity: .6},
+	SoilTemperature:          {name: "soil_temperature", v2: "TS0", opacity: .6},
+	Humidity:                 {name: "humidity", v2: "HRD0", opacity: .6},
+	Cloudiness:               {name: "cloudiness", v2: "CL", opacity: .8, legend: cloudsLegend},
+}
+
+func (m Map) String() string {
+	if m == Base {
+		return "base"
+	}
+	if l, ok := layers[m]; ok {
+		return l.name
+	}
+	return fmt.Sprintf("Map(%d)", int(m))
+}
+
+// ParseMap returns the map layer with name, either its name here, its
+// Weather Maps 1.0 layer name or its 2.0 operation code.
+func ParseMap(name string) (Map, error) {
+	if strings.EqualFold(name, "base") {
+		return Base, nil
+	}
+	for m, l := range layers {
+		for _, n := range []string{l.name, l.v1, l.v2} {
+			if n != "" && strings.EqualFold(n, name) {
+				return m, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown OpenWeatherMap layer '%s'", name)
+}
+
+// layerSource returns the tile source for l.
+func (c *Client) layerSource(l *layer) *mosaic.XYZSource {
+	s := &mosaic.XYZSource{
+		Source: mosaic.Source{
+			Name:        fmt.Sprintf("OpenWeatherMap %s", l.name),
+			Attribution: "Weather data © OpenWeatherMap",
+			APIKey:      c.APIKey,
+			Client:      c.HTTPClient,
+		},
+	}
+	if l.v2 != "" {
+		s.URLTemplate = fmt.Sprintf("%s/weather/%s/{z}/{x}/{y}?appid={apikey}", c.MapsURL, l.v2)
+	} else {
+		s.URLTemplate = fmt.Sprintf("%s/%s/{z}/{x}/{y}.png?appid={apikey}", c.TileURL, l.v1)
+	}
+	return s
+}
@@ -1,18 +1,12 @@
 package openweathermap
 
 import (
-	"encoding/json"
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
-	"image/png"
-	"math"
-	"net/http"
-	"os"
 	"regexp"
 
-	"github.com/disintegration/imaging"
+	"github.com/gigawhitlocks/weather/geocoding"
+	"github.com/gigawhitlocks/weather/mosaic"
 )
 
 var CityStatePattern, _ = regexp.Compile("[A-Z a-z]+(,?[ \t]+[A-Za-z]+)?")
@@ -31,16 +25,6 @@ type Location struct {
 	Coordinates `json:"coord"`
 }
 
-func tileNumbers(lat, long float64, zoom int) (int, int) {
-	latRad := lat * (math.Pi / 180)
-	n := math.Pow(2.0, float64(zoom))
-	xtile := int((long + 180.0) / 360.0 * n)
-	ytile := int((1.0 - math.Log(math.Tan(latRad)+(1/math.Cos(latRad)))/math.Pi) / 2.0 * n)
-	return xtile, ytile
-}
-
-var APIKEY = os.Getenv("OWM_API_KEY")
-
 type Map int
 
 const (
@@ -48,200 +32,150 @@ const (
 	Base Map = 1 + iota
 	Clouds
 	Precipitation
+	Temperature
+	Wind
+	Pressure
+
+	// Weather Maps 2.0 layers
+	PrecipitationIntensity
+	ConvectivePrecipitation
+	AccumulatedPrecipitation
+	AccumulatedRain
+	AccumulatedSnow
+	SnowDepth
+	WindSpeed
+	WindDirection
+	SeaLevelPressure
+	AirTemperature
+	DewPoint
+	SoilTemperature
+	Humidity
+	Cloudiness
 )
 
-func GetTiles(xtile, ytile int, mt Map) (*image.NRGBA, error) {
-	var baseurl string
-	var querystring string
-
+// tileSource returns the tile source for a map type.
+func (c *Client) tileSource(mt Map) (mosaic.TileSource, error) {
 	if mt == Base {
-		baseurl = "https://sat.owm.io/sql"
-		// querystring = fmt.Sprintf("?APPID=%s&op=rgb&from=l8&select=b4,b3,b2&order=best", APIKEY)
-		querystring = fmt.Sprintf("?APPID=%s&op=rgb&from=cloudless&select=red,green,blue&order=best", APIKEY)
-	} else if mt == Clouds || mt == Precipitation {
-		baseurl = "https://tile.openweathermap.org/map"
-		var layer string
-		if mt == Clouds {
-			layer = "/clouds_new"
-		} else {
-			layer = "/precipitation_new"
-		}
-		querystring = fmt.Sprintf(".png?cities=true&appid=%s", APIKEY)
-		baseurl = fmt.Sprintf("%s%s", baseurl, layer)
-	} else {
-		return nil, fmt.Errorf("Unrecognized map type requested")
+		return &mosaic.XYZSource{
+			Source: mosaic.Source{
+				Name:        "OpenWeatherMap satellite",
+				Attribution: "Imagery © OpenWeatherMap",
+				APIKey:      c.APIKey,
+				Client:      c.HTTPClient,
+			},
+			// URLTemplate: c.SatelliteURL + "/sql/{z}/{x}/{y}?APPID={apikey}&op=rgb&from=l8&select=b4,b3,b2&order=best",
+			URLTemplate: c.SatelliteURL + "/sql/{z}/{x}/{y}?APPID={apikey}&op=rgb&from=cloudless&select=red,green,blue&order=best",
+		}, nil
 	}
-
-	// get the images
-	type imagePos struct {
-		Image *image.Image
-		X     int
-		Y     int
-	}
-
-	imagesChannel := make(chan interface{}, 9)
-	for x := 0; x < 3; x++ {
-		for y := 0; y < 3; y++ {
-			url := fmt.Sprintf(
-				"%s/%d/%d/%d%s",
-				baseurl,
-				zoom,
-				xtile+(x-1),
-				ytile+(y-1),
-				querystring)
-			fmt.Printf("%s\n", url)
-
-			go func(x, y int, url string) {
-				var resp *http.Response
-				var err error
-				if resp, err = http.Get(url); err != nil {
-					imagesChannel <- err
-					return
-				}
-				var satellite image.Image
-				satellite, err = png.Decode(resp.Body)
-				if err != nil {
-					imagesChannel <- err
-					return
-				}
-
-				imagesChannel <- imagePos{
-					Image: &satellite,
-					X:     x,
-					Y:     y,
-				}
-			}(x, y, url)
-		}
+	if l, ok := layers[mt]; ok {
+		return c.layerSource(l), nil
 	}
+	return nil, fmt.Errorf("Unrecognized map type requested")
+}
 
-	images := [3][3]*image.Image{
-		{nil, nil, nil},
-		{nil, nil, nil},
-		{nil, nil, nil},
+// viewport is a 3x3 tile sized area centered on center.
+func viewport(center *geocoding.Coordinates) *mosaic.Viewport {
+	return &mosaic.Viewport{
+		Center: *center,
+		Zoom:   zoom,
+		Width:  3 * geocoding.TileSize,
+		Height: 3 * geocoding.TileSize,
 	}
+}
 
-	for i := 0; i < 9; i++ {
-		im := <-imagesChannel
-		switch im := im.(type) {
-		case error:
-			return nil, im
-		case imagePos:
-			if im.Image == nil {
-				return nil, fmt.Errorf("Image was nil")
-			}
-			images[im.X][im.Y] = im.Image
-		}
+// renderMap renders a 3x3 tile sized map of type mt centered on center.
+func (c *Client) renderMap(center *geocoding.Coordinates, mt Map) (*image.NRGBA, error) {
+	source, err := c.tileSource(mt)
+	if err != nil {
+		return nil, err
 	}
-
-	return stitchTiles(images), nil
+	return mosaic.RenderLayer(viewport(center), source)
 }
 
-func GetTileNumbers(query string) (*Location, error) {
-	var err error
-	var url string
-
-	switch {
-	case ZipPattern.MatchString(query):
-		url = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?zip=%s,us&appid=%s",
-			query, APIKEY)
-	default:
-		return nil, fmt.Errorf("Satellite endpoint only accepts zip codes :(")
-	}
+// GetTiles returns the 3x3 block of tiles of type mt around tile xtile,
+// ytile.
+func (c *Client) GetTiles(xtile, ytile int, mt Map) (*image.NRGBA, error) {
+	center := geocoding.PixelToCoordinates(
+		(float64(xtile)+.5)*geocoding.TileSize, (float64(ytile)+.5)*geocoding.TileSize,
+		zoom, geocoding.TileSize)
+	return c.renderMap(center, mt)
+}
 
-	var resp *http.Response
-	if resp, err = http.Get(url); err != nil {
+// GetTileNumbers returns the location of query, a US ZIP code or a city
+// name like "Austin,TX,US".
+func (c *Client) GetTileNumbers(query string) (*Location, error) {
+	places, err := c.Geocode(query, 1)
+	if err != nil {
 		return nil, err
 	}
-
-	c := new(Location)
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(c); err != nil {
-		return nil, err
+	if len(places) == 0 {
+		return nil, fmt.Errorf("no results for '%s'", query)
 	}
+	return &Location{Coordinates: Coordinates{Lat: places[0].Lat, Long: places[0].Lon}}, nil
+}
+
+func (l *Location) coordinates() *geocoding.Coordinates {
+	return l.Coordinates.LatLong()
+}
 
-	return c, nil
+func (c *Client) GetSatellite(l *Location) (*image.NRGBA, error) {
+	return c.renderMap(l.coordinates(), Base)
 }
 
-func GetSatellite(c *Location) (*image.NRGBA, error) {
-	xtile, ytile := tileNumbers(c.Lat, c.Long, zoom)
-	return GetTiles(xtile, ytile, Base)
+// Overlay is a weather layer drawn over the satellite basemap of a
+// composite. Opacity between 0 and 1 defaults to a value suited to the
+// layer's palette when zero.
+type Overlay struct {
+	Map     Map
+	Opacity float64
 }
 
-func GetComposite(query string) (*image.NRGBA, error) {
-	l, err := GetTileNumbers(query)
+// GetComposite renders overlays over satellite imagery around query,
+// annotated with its location, a scale bar, a legend for each overlay and
+// attribution. Without overlays it shows clouds and precipitation.
+func (c *Client) GetComposite(query string, overlays ...*Overlay) (*image.NRGBA, error) {
+	if len(overlays) == 0 {
+		overlays = []*Overlay{{Map: Clouds}, {Map: Precipitation}}
+	}
+	base, err := c.tileSource(Base)
 	if err != nil {
 		return nil, err
 	}
-	type MapResult struct {
-		self    *image.NRGBA
-		mapType Map
-	}
-	xtile, ytile := tileNumbers(l.Lat, l.Long, zoom)
-	results := make(chan interface{}, 3)
-
-	go func() {
-		var basemap *image.NRGBA
-		if basemap, err = GetSatellite(l); err != nil {
-			results <- err
+	mosaicLayers := []*mosaic.Layer{{Source: base}}
+	legends := []*mosaic.Legend{}
+	for _, overlay := range overlays {
+		l, ok := layers[overlay.Map]
+		if !ok {
+			return nil, fmt.Errorf("%s can't be used as an overlay", overlay.Map)
 		}
-		results <- &MapResult{
-			self:    basemap,
-			mapType: Base,
+		if overlay.Opacity < 0 || overlay.Opacity > 1 {
+			return nil, fmt.Errorf("opacity %g for %s is out of range; expected 0 to 1", overlay.Opacity, overlay.Map)
 		}
-	}()
-
-	// go func() {
-	// 	var basemap *image.NRGBA
-	// 	if basemap, err = GetTiles(xtile, ytile, Clouds); err != nil {
-	// 		results <- err
-	// 	}
-	// 	results <- &MapResult{
-	// 		self:    basemap,
-	// 		mapType: Clouds,
-	// 	}
-	// }()
-
-	go func() {
-		var basemap *image.NRGBA
-		if basemap, err = GetTiles(xtile, ytile, Precipitation); err != nil {
-			results <- err
+		opacity := overlay.Opacity
+		if opacity == 0 {
+			opacity = l.opacity
 		}
-		results <- &MapResult{
-			self:    basemap,
-			mapType: Precipitation,
-		}
-
-	}()
-
-	im := make(map[Map]*image.NRGBA)
-	for x := 0; x < 2; x++ {
-		r := <-results
-		switch r := r.(type) {
-		case *MapResult:
-			im[r.mapType] = r.self
-		case error:
-			return nil, r
+		mosaicLayers = append(mosaicLayers, &mosaic.Layer{Source: c.layerSource(l), Opacity: opacity})
+		if l.legend != nil {
+			legends = append(legends, l.legend)
 		}
 	}
 
-	result := im[Base]
-	// result = imaging.Overlay(result, im[Precipitation], image.Pt(0, 0), .5)
-	m := image.NewRGBA(image.Rect(0, 0, 512*3, 512*3))
-	green := color.RGBA{0, 255, 0, 255}
-	draw.Draw(m, m.Bounds(), &image.Uniform{green}, image.ZP, draw.Src)
-	draw.DrawMask(m, m.Bounds(), m, image.Pt(0, 0), im[Precipitation], image.Pt(0, 0), draw.Src)
-
-	result = imaging.Overlay(result, m, image.Pt(0, 0), .9)
-	return result, nil
-}
-
-func stitchTiles(images [3][3]*image.Image) *image.NRGBA {
-	// stitch together the tiles
-	dst := imaging.New(768, 768, color.NRGBA{0, 0, 0, 0})
-	for x := 0; x < len(images); x++ {
-		for y := 0; y < len(images[x]); y++ {
-			dst = imaging.Paste(dst, *images[x][y], image.Pt(256*x, 256*y))
-		}
+	l, err := c.GetTileNumbers(query)
+	if err != nil {
+		return nil, err
 	}
-	return dst
+	v := viewport(l.coordinates())
+	result, err := mosaic.Render(v, mosaicLayers...)
+	if err != nil {
+		return nil, err
+	}
+	mosaic.Annotate(result, v, &mosaic.Annotations{
+		Marker:      l.coordinates(),
+		Label:       query,
+		ScaleBar:    true,
+		Legends:     legends,
+		Attribution: mosaic.Attribution(mosaicLayers...),
+	})
+	return result, nil
 }
@@ -0,0 +1,47 @@
+package openweathermap
+
+import (
+	"testing"
+
+	"github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMap(t *testing.T) {
+	for name, want := range map[string]Map{
+		"base":          Base,
+		"temp":          Temperature,
+		"temp_new":      Temperature,
+		"Precipitation": Precipitation,
+		"TA2":           AirTemperature,
+		"ws10":          WindSpeed,
+	} {
+		m, err := ParseMap(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, m, name)
+	}
+	_, err := ParseMap("aurora")
+	assert.Error(t, err)
+	assert.Equal(t, "wind", Wind.String())
+}
+
+func TestLayerSources(t *testing.T) {
+	tile := geocoding.NewSlippyMapTile(29, 52, 7)
+	for m, l := range layers {
+		source := NewClient("key").layerSource(l)
+		if l.v2 != "" {
+			assert.Equal(t, "https://maps.openweathermap.org/maps/2.0/weather/"+l.v2+"/7/29/52?appid=key", source.URL(tile), m.String())
+		} else {
+			assert.Equal(t, "https://tile.openweathermap.org/map/"+l.v1+"/7/29/52.png?appid=key", source.URL(tile), m.String())
+		}
+	}
+}
+
+func TestGetCompositeRejectsBadOverlays(t *testing.T) {
+	c := NewClient("key")
+	_, err := c.GetComposite("78701", &Overlay{Map: Base})
+	assert.Error(t, err)
+	_, err = c.GetComposite("78701", &Overlay{Map: Clouds, Opacity: 1.5})
+	assert.Error(t, err)
+}
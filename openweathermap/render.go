@@ -0,0 +1,101 @@
+package openweathermap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/render"
+)
+
+// temperatureUnit and speedUnit label measurements in units.
+func (u Units) temperatureUnit() string {
+	switch u {
+	case Metric:
+		return "°C"
+	case Imperial:
+		return "°F"
+	default:
+		return "K"
+	}
+}
+
+func (u Units) speedUnit() string {
+	if u == Imperial {
+		return "mph"
+	}
+	return "m/s"
+}
+
+// report returns the first of conditions and its description.
+func report(weather []Condition) (conditions.Report, string) {
+	if len(weather) == 0 {
+		return conditions.Report{}, ""
+	}
+	return weather[0].Report(), strings.Title(weather[0].Description)
+}
+
+// zone returns the time zone offset by seconds from UTC.
+func zone(seconds int) *time.Location {
+	return time.FixedZone("", seconds)
+}
+
+// Render returns the weather for any of the render package's renderers,
+// labeling measurements in units, which must be the units it was requested
+// in.
+func (w *CurrentWeather) Render(units Units) *render.Observation {
+	loc := zone(w.Timezone)
+	r := &render.Observation{Location: w.Name, Time: render.At(w.Time.In(loc))}
+	r.Conditions, r.Description = report(w.Weather)
+	r.Fields = []render.Field{
+		{Label: "Temperature", Value: fmt.Sprintf("%.1f %s", w.Main.Temp, units.temperatureUnit())},
+		{Label: "Feels Like", Value: fmt.Sprintf("%.1f %s", w.Main.FeelsLike, units.temperatureUnit())},
+		{Label: "Humidity", Value: fmt.Sprintf("%.0f%%", w.Main.Humidity)},
+		{Label: "Pressure", Value: fmt.Sprintf("%.0f hPa", w.Main.Pressure)},
+		{Label: "Wind", Value: fmt.Sprintf("%.1f %s", w.Wind.Speed, units.speedUnit())},
+		{Label: "Cloud Cover", Value: fmt.Sprintf("%.0f%%", w.Clouds.All)},
+	}
+	if w.Rain.OneHour > 0 {
+		r.Fields = append(r.Fields, render.Field{Label: "Rain", Value: fmt.Sprintf("%.1f mm/h", w.Rain.OneHour)})
+	}
+	if w.Snow.OneHour > 0 {
+		r.Fields = append(r.Fields, render.Field{Label: "Snow", Value: fmt.Sprintf("%.1f mm/h", w.Snow.OneHour)})
+	}
+	if !w.Sys.Sunrise.IsZero() {
+		r.Fields = append(r.Fields,
+			render.Field{Label: "Sunrise", Value: w.Sys.Sunrise.In(loc).Format("3:04 PM")},
+			render.Field{Label: "Sunset", Value: w.Sys.Sunset.In(loc).Format("3:04 PM")})
+	}
+	return r
+}
+
+// Render returns the forecast for any of the render package's renderers,
+// labeling measurements in units, which must be the units it was requested
+// in.
+func (f *Forecast) Render(units Units) *render.Forecast {
+	loc := zone(f.City.Timezone)
+	r := &render.Forecast{Title: "3 Hour Forecast", Location: f.City.Name}
+	for _, item := range f.List {
+		p := &render.Period{Name: item.Time.In(loc).Format("Mon 3 PM"), Time: render.At(item.Time.In(loc))}
+		p.Conditions, p.Description = report(item.Weather)
+		p.Fields = []render.Field{
+			{Label: "Temperature", Value: fmt.Sprintf("%.0f %s", item.Main.Temp, units.temperatureUnit())},
+			{Label: "Precipitation", Value: fmt.Sprintf("%.0f%%", item.Pop*100)},
+			{Label: "Wind", Value: fmt.Sprintf("%.0f %s", item.Wind.Speed, units.speedUnit())},
+		}
+		r.Periods = append(r.Periods, p)
+	}
+	return r
+}
+
+// Render returns the alert for any of the render package's renderers.
+func (a *Alert) Render() *render.Alert {
+	return &render.Alert{
+		Event:       a.Event,
+		Description: a.Description,
+		Sender:      a.SenderName,
+		Onset:       render.At(a.Start.Time),
+		Expires:     render.At(a.End.Time),
+	}
+}
@@ -0,0 +1,381 @@
+package openweathermap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/gigawhitlocks/weather/geocoding"
+)
+
+// Units selects the units of measurement in responses.
+type Units string
+
+const (
+	// Standard units are Kelvin and meters per second.
+	Standard Units = "standard"
+	// Metric units are Celsius and meters per second.
+	Metric Units = "metric"
+	// Imperial units are Fahrenheit and miles per hour.
+	Imperial Units = "imperial"
+)
+
+// Time is a time sent by OWM as seconds since the epoch.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s: %w", b, err)
+	}
+	t.Time = time.Unix(seconds, 0).UTC()
+	return nil
+}
+
+// Condition is one of OWM's weather condition codes, e.g. 500 for light
+// rain, with its description and icon.
+type Condition struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Report returns the provider independent condition, at day or night.
+func (c *Condition) Report() conditions.Report {
+	return conditions.FromOpenWeatherMap(c.ID, c.Icon)
+}
+
+// Volume is rain or snow in millimeters over the last hour or three.
+type Volume struct {
+	OneHour   float64 `json:"1h"`
+	ThreeHour float64 `json:"3h"`
+}
+
+// Measurements are the main readings of current weather and forecasts.
+type Measurements struct {
+	Temp      float64 `json:"temp"`
+	FeelsLike float64 `json:"feels_like"`
+	TempMin   float64 `json:"temp_min"`
+	TempMax   float64 `json:"temp_max"`
+	// Pressure is in hectopascals.
+	Pressure    float64 `json:"pressure"`
+	SeaLevel    float64 `json:"sea_level"`
+	GroundLevel float64 `json:"grnd_level"`
+	Humidity    float64 `json:"humidity"`
+}
+
+// WindReading is wind speed and gusts in the request's units, and the
+// direction it blows from in degrees.
+type WindReading struct {
+	Speed float64 `json:"speed"`
+	Deg   float64 `json:"deg"`
+	Gust  float64 `json:"gust"`
+}
+
+// CloudCover is the percentage of the sky covered by clouds.
+type CloudCover struct {
+	All float64 `json:"all"`
+}
+
+// CurrentWeather is the response of the current weather API.
+type CurrentWeather struct {
+	ID      int          `json:"id"`
+	Name    string       `json:"name"`
+	Coord   Coordinates  `json:"coord"`
+	Weather []Condition  `json:"weather"`
+	Main    Measurements `json:"main"`
+	// Visibility is in meters.
+	Visibility float64     `json:"visibility"`
+	Wind       WindReading `json:"wind"`
+	Clouds     CloudCover  `json:"clouds"`
+	Rain       Volume      `json:"rain"`
+	Snow       Volume      `json:"snow"`
+	Time       Time        `json:"dt"`
+	Sys        struct {
+		Country string `json:"country"`
+		Sunrise Time   `json:"sunrise"`
+		Sunset  Time   `json:"sunset"`
+	} `json:"sys"`
+	// Timezone is the offset from UTC in seconds.
+	Timezone int `json:"timezone"`
+}
+
+// ForecastItem is the forecast for one three hour period.
+type ForecastItem struct {
+	Time       Time         `json:"dt"`
+	Main       Measurements `json:"main"`
+	Weather    []Condition  `json:"weather"`
+	Clouds     CloudCover   `json:"clouds"`
+	Wind       WindReading  `json:"wind"`
+	Visibility float64      `json:"visibility"`
+	// Pop is the probability of precipitation between 0 and 1.
+	Pop  float64 `json:"pop"`
+	Rain Volume  `json:"rain"`
+	Snow Volume  `json:"snow"`
+}
+
+// Forecast is the response of the 5 day / 3 hour forecast API.
+type Forecast struct {
+	List []ForecastItem `json:"list"`
+	City struct {
+		ID       int         `json:"id"`
+		Name     string      `json:"name"`
+		Coord    Coordinates `json:"coord"`
+		Country  string      `json:"country"`
+		Timezone int         `json:"timezone"`
+		Sunrise  Time        `json:"sunrise"`
+		Sunset   Time        `json:"sunset"`
+	} `json:"city"`
+}
+
+// OneCallCurrent is the current weather in a One Call response.
+type OneCallCurrent struct {
+	Time       Time        `json:"dt"`
+	Sunrise    Time        `json:"sunrise"`
+	Sunset     Time        `json:"sunset"`
+	Temp       float64     `json:"temp"`
+	FeelsLike  float64     `json:"feels_like"`
+	Pressure   float64     `json:"pressure"`
+	Humidity   float64     `json:"humidity"`
+	DewPoint   float64     `json:"dew_point"`
+	UVI        float64     `json:"uvi"`
+	Clouds     float64     `json:"clouds"`
+	Visibility float64     `json:"visibility"`
+	WindSpeed  float64     `json:"wind_speed"`
+	WindDeg    float64     `json:"wind_deg"`
+	WindGust   float64     `json:"wind_gust"`
+	Weather    []Condition `json:"weather"`
+	Rain       Volume      `json:"rain"`
+	Snow       Volume      `json:"snow"`
+}
+
+// OneCallMinute is the precipitation forecast for a minute, in mm/h.
+type OneCallMinute struct {
+	Time          Time    `json:"dt"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+// OneCallHour is the forecast for an hour.
+type OneCallHour struct {
+	OneCallCurrent
+	Pop float64 `json:"pop"`
+}
+
+// DailyTemperatures are a day's temperatures at different times of day.
+type DailyTemperatures struct {
+	Morning float64 `json:"morn"`
+	Day     float64 `json:"day"`
+	Evening float64 `json:"eve"`
+	Night   float64 `json:"night"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// OneCallDay is the forecast for a day. Rain and Snow are the day's totals
+// in millimeters.
+type OneCallDay struct {
+	Time      Time              `json:"dt"`
+	Sunrise   Time              `json:"sunrise"`
+	Sunset    Time              `json:"sunset"`
+	Moonrise  Time              `json:"moonrise"`
+	Moonset   Time              `json:"moonset"`
+	MoonPhase float64           `json:"moon_phase"`
+	Temp      DailyTemperatures `json:"temp"`
+	FeelsLike DailyTemperatures `json:"feels_like"`
+	Pressure  float64           `json:"pressure"`
+	Humidity  float64           `json:"humidity"`
+	DewPoint  float64           `json:"dew_point"`
+	WindSpeed float64           `json:"wind_speed"`
+	WindDeg   float64           `json:"wind_deg"`
+	WindGust  float64           `json:"wind_gust"`
+	Weather   []Condition       `json:"weather"`
+	Clouds    float64           `json:"clouds"`
+	Pop       float64           `json:"pop"`
+	Rain      float64           `json:"rain"`
+	Snow      float64           `json:"snow"`
+	UVI       float64           `json:"uvi"`
+}
+
+// Alert is a government weather alert.
+type Alert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       Time     `json:"start"`
+	End         Time     `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// OneCall is the response of the One Call API.
+type OneCall struct {
+	Lat            float64         `json:"lat"`
+	Lon            float64         `json:"lon"`
+	Timezone       string          `json:"timezone"`
+	TimezoneOffset int             `json:"timezone_offset"`
+	Current        *OneCallCurrent `json:"current"`
+	Minutely       []OneCallMinute `json:"minutely"`
+	Hourly         []OneCallHour   `json:"hourly"`
+	Daily          []OneCallDay    `json:"daily"`
+	Alerts         []Alert         `json:"alerts"`
+}
+
+// Parts of a One Call response that can be excluded.
+const (
+	ExcludeCurrent  = "current"
+	ExcludeMinutely = "minutely"
+	ExcludeHourly   = "hourly"
+	ExcludeDaily    = "daily"
+	ExcludeAlerts   = "alerts"
+)
+
+// Location returns the time zone the response's times are local to.
+func (o *OneCall) Location() *time.Location {
+	if loc, err := time.LoadLocation(o.Timezone); err == nil && o.Timezone != "" {
+		return loc
+	}
+	return time.FixedZone("", o.TimezoneOffset)
+}
+
+// LatLong converts c for use with the rest of the library.
+func (c Coordinates) LatLong() *geocoding.Coordinates {
+	return &geocoding.Coordinates{Latitude: c.Lat, Longitude: c.Long}
+}
+
+// Location returns the time zone of the weather's location.
+func (w *CurrentWeather) Location() *time.Location {
+	return time.FixedZone("", w.Timezone)
+}
+
+// apiError is the body OWM sends with errors.
+type apiError struct {
+	Code    json.RawMessage `json:"cod"`
+	Message string          `json:"message"`
+}
+
+// queryParams returns the parameters that select query: a US ZIP code or a
+// city name like "Austin,TX,US".
+func queryParams(query string) url.Values {
+	q := url.Values{}
+	if ZipPattern.MatchString(query) && len(strings.TrimSpace(query)) == 5 {
+		q.Set("zip", strings.TrimSpace(query)+",us")
+	} else {
+		q.Set("q", query)
+	}
+	return q
+}
+
+func coordinateParams(c *geocoding.Coordinates) url.Values {
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(c.Latitude, 'f', 4, 64))
+	q.Set("lon", strconv.FormatFloat(c.Longitude, 'f', 4, 64))
+	return q
+}
+
+// get requests endpoint of the weather API with params and decodes the
+// response into v.
+func (c *Client) get(endpoint string, params url.Values, v interface{}) error {
+	return c.getFrom(c.APIURL, endpoint, params, v)
+}
+
+// getFrom requests endpoint of the API at base.
+func (c *Client) getFrom(base, endpoint string, params url.Values, v interface{}) error {
+	params.Set("appid", c.APIKey)
+	rawurl := base + endpoint + "?" + params.Encode()
+	c.logf("GET %s", redact(rawurl))
+	resp, err := c.httpClient().Get(rawurl)
+	if err != nil {
+		return fmt.Errorf("failed to get %s from OpenWeatherMap: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := new(apiError)
+		if json.Unmarshal(body, e) == nil && e.Message != "" {
+			return fmt.Errorf("OpenWeatherMap %s returned %d: %s", endpoint, resp.StatusCode, e.Message)
+		}
+		return fmt.Errorf("OpenWeatherMap %s returned %d", endpoint, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// GetCurrentWeather returns the current weather for query, a US ZIP code or
+// a city name.
+func (c *Client) GetCurrentWeather(query string, units Units) (*CurrentWeather, error) {
+	params := queryParams(query)
+	params.Set("units", string(units))
+	w := new(CurrentWeather)
+	if err := c.get("/weather", params, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// GetCurrentWeatherAt returns the current weather at coords.
+func (c *Client) GetCurrentWeatherAt(coords *geocoding.Coordinates, units Units) (*CurrentWeather, error) {
+	params := coordinateParams(coords)
+	params.Set("units", string(units))
+	w := new(CurrentWeather)
+	if err := c.get("/weather", params, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// GetForecast returns the 5 day forecast in 3 hour steps for query, a US
+// ZIP code or a city name.
+func (c *Client) GetForecast(query string, units Units) (*Forecast, error) {
+	params := queryParams(query)
+	params.Set("units", string(units))
+	f := new(Forecast)
+	if err := c.get("/forecast", params, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetForecastAt returns the 5 day forecast in 3 hour steps at coords.
+func (c *Client) GetForecastAt(coords *geocoding.Coordinates, units Units) (*Forecast, error) {
+	params := coordinateParams(coords)
+	params.Set("units", string(units))
+	f := new(Forecast)
+	if err := c.get("/forecast", params, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetOneCall returns the current weather, minutely forecast for the next
+// hour, hourly forecast for two days, daily forecast for a week and alerts
+// at coords, leaving out the parts in exclude. It uses One Call 3.0, which
+// needs its own subscription ("One Call by Call") on top of a free key;
+// One Call 2.5 has been shut down.
+func (c *Client) GetOneCall(coords *geocoding.Coordinates, units Units, exclude ...string) (*OneCall, error) {
+	params := coordinateParams(coords)
+	params.Set("units", string(units))
+	if len(exclude) > 0 {
+		params.Set("exclude", strings.Join(exclude, ","))
+	}
+	o := new(OneCall)
+	if err := c.getFrom(c.OneCallURL, "/onecall", params, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
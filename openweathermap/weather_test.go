@@ -0,0 +1,117 @@
+package openweathermap
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/geocoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCurrentWeather(t *testing.T) {
+	w := new(CurrentWeather)
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"coord": {"lon": -97.74, "lat": 30.27},
+		"weather": [{"id": 500, "main": "Rain", "description": "light rain", "icon": "10d"}],
+		"main": {"temp": 88.3, "feels_like": 95.1, "temp_min": 86, "temp_max": 90, "pressure": 1015, "humidity": 55},
+		"visibility": 10000,
+		"wind": {"speed": 9.2, "deg": 160, "gust": 15},
+		"clouds": {"all": 40},
+		"rain": {"1h": 0.25},
+		"dt": 1596297600,
+		"sys": {"country": "US", "sunrise": 1596281000, "sunset": 1596330000},
+		"timezone": -18000,
+		"id": 4671654,
+		"name": "Austin"
+	}`), w))
+	assert.Equal(t, "Austin", w.Name)
+	assert.Equal(t, 500, w.Weather[0].ID)
+	assert.Equal(t, 88.3, w.Main.Temp)
+	assert.Equal(t, .25, w.Rain.OneHour)
+	assert.Equal(t, time.Date(2020, 8, 1, 16, 0, 0, 0, time.UTC), w.Time.Time)
+	assert.Equal(t, "11:00", w.Time.In(w.Location()).Format("15:04"))
+	assert.InDelta(t, 30.27, w.Coord.LatLong().Latitude, 1e-9)
+}
+
+func TestDecodeOneCall(t *testing.T) {
+	o := new(OneCall)
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"lat": 30.27, "lon": -97.74, "timezone": "America/Chicago", "timezone_offset": -18000,
+		"current": {"dt": 1596297600, "temp": 88.3, "uvi": 9.1, "weather": [{"id": 800, "main": "Clear"}]},
+		"minutely": [{"dt": 1596297600, "precipitation": 0}, {"dt": 1596297660, "precipitation": 0.5}],
+		"hourly": [{"dt": 1596297600, "temp": 88.3, "pop": 0.2}],
+		"daily": [{"dt": 1596304800, "temp": {"min": 75, "max": 98}, "pop": 0.4, "rain": 2.5}],
+		"alerts": [{"sender_name": "NWS Austin/San Antonio", "event": "Heat Advisory", "start": 1596297600, "end": 1596330000}]
+	}`), o))
+	assert.Equal(t, 9.1, o.Current.UVI)
+	require.Len(t, o.Minutely, 2)
+	assert.Equal(t, .5, o.Minutely[1].Precipitation)
+	assert.Equal(t, .2, o.Hourly[0].Pop)
+	assert.Equal(t, 88.3, o.Hourly[0].Temp)
+	assert.Equal(t, 98.0, o.Daily[0].Temp.Max)
+	assert.Equal(t, "Heat Advisory", o.Alerts[0].Event)
+	assert.Equal(t, "America/Chicago", o.Location().String())
+}
+
+func TestGetOneCall(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/data/3.0/onecall", r.URL.Path)
+		assert.Equal(t, "minutely,alerts", r.URL.Query().Get("exclude"))
+		w.Write([]byte(`{"timezone": "America/Chicago", "current": {"temp": 88.3}}`))
+	})
+	o, err := c.GetOneCall(&geocoding.Coordinates{Latitude: 30.27, Longitude: -97.74}, Imperial, ExcludeMinutely, ExcludeAlerts)
+	require.NoError(t, err)
+	assert.Equal(t, 88.3, o.Current.Temp)
+}
+
+func TestDecodeForecast(t *testing.T) {
+	f := new(Forecast)
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"list": [{"dt": 1596304800, "main": {"temp": 90}, "pop": 0.3, "rain": {"3h": 1.2}}],
+		"city": {"name": "Austin", "country": "US", "timezone": -18000}
+	}`), f))
+	assert.Equal(t, "Austin", f.City.Name)
+	assert.Equal(t, 1.2, f.List[0].Rain.ThreeHour)
+	assert.Equal(t, .3, f.List[0].Pop)
+}
+
+func TestQueryParams(t *testing.T) {
+	assert.Equal(t, "78701,us", queryParams("78701").Get("zip"))
+	assert.Equal(t, "Austin,TX,US", queryParams("Austin,TX,US").Get("q"))
+}
+
+func TestClientGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("appid") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"cod": 401, "message": "Invalid API key."}`))
+			return
+		}
+		assert.Equal(t, "/weather", r.URL.Path)
+		assert.Equal(t, "imperial", r.URL.Query().Get("units"))
+		w.Write([]byte(`{"name": "Austin", "main": {"temp": 88.3}}`))
+	}))
+	defer server.Close()
+
+	logs := new(bytes.Buffer)
+	c := NewClient("secret")
+	c.APIURL = server.URL
+	c.Logger = log.New(logs, "", 0)
+	w, err := c.GetCurrentWeather("Austin,TX,US", Imperial)
+	require.NoError(t, err)
+	assert.Equal(t, "Austin", w.Name)
+	assert.Contains(t, logs.String(), "appid=REDACTED")
+	assert.NotContains(t, logs.String(), "secret")
+
+	c.APIKey = "wrong"
+	w, err = c.GetCurrentWeather("Austin,TX,US", Imperial)
+	require.Error(t, err)
+	assert.Nil(t, w)
+	assert.Contains(t, err.Error(), "Invalid API key.")
+}
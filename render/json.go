@@ -0,0 +1,18 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSON renders weather as indented JSON, with conditions named by their
+// slugs.
+type JSON struct{}
+
+var _ Renderer = JSON{}
+
+func (JSON) Render(w io.Writer, weather *Weather) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(weather)
+}
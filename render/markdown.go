@@ -0,0 +1,95 @@
+package render
+
+import (
+	"io"
+	"strings"
+)
+
+// Markdown renders observations and forecasts as Markdown tables, and
+// alerts as headed paragraphs.
+type Markdown struct{}
+
+var _ Renderer = Markdown{}
+
+func (Markdown) Render(w io.Writer, weather *Weather) error {
+	out := &writer{w: w}
+	sections := 0
+	section := func() {
+		if sections > 0 {
+			out.printf("\n")
+		}
+		sections++
+	}
+	if o := weather.Observation; o != nil {
+		section()
+		out.printf("%s\n| :--- | ---: | :--- | ---: |\n",
+			row("Current Conditions", headline(o.Title(), o.Conditions), "Location", o.Location))
+		fields := o.Fields
+		if o.Station != "" {
+			fields = append(fields[:len(fields):len(fields)], Field{Label: "Station", Value: o.Station})
+		}
+		if o.Time != nil {
+			fields = append(fields[:len(fields):len(fields)], Field{Label: "Observed", Value: o.Time.Format(TimeFormat)})
+		}
+		for i := 0; i < len(fields); i += 2 {
+			right := Field{}
+			if i+1 < len(fields) {
+				right = fields[i+1]
+			}
+			out.printf("%s\n", row(fields[i].Label, fields[i].Value, right.Label, right.Value))
+		}
+	}
+	if f := weather.Forecast; f != nil {
+		section()
+		labels := f.labels()
+		out.printf("%s\n", row(append([]string{f.Title, f.Location}, make([]string, len(labels))...)...))
+		out.printf("%s\n", row(append([]string{"Period", "Conditions"}, labels...)...))
+		out.printf("| :--- | :--- |%s\n", strings.Repeat(" ---: |", len(labels)))
+		for _, p := range f.Periods {
+			values := map[string]string{}
+			for _, field := range p.Fields {
+				values[field.Label] = field.Value
+			}
+			cells := []string{p.Name, headline(p.Title(), p.Conditions)}
+			for _, label := range labels {
+				cells = append(cells, values[label])
+			}
+			out.printf("%s\n", row(cells...))
+		}
+	}
+	for _, a := range weather.Alerts {
+		section()
+		out.printf("#### %s %s\n", warning, alertTitle(a))
+		for _, s := range []string{bold(a.Headline), a.Description, a.Instruction} {
+			if s != "" {
+				out.printf("%s\n", s)
+			}
+		}
+	}
+	return out.err
+}
+
+// row formats cells as a table row.
+func row(cells ...string) string {
+	s := "|"
+	for _, c := range cells {
+		if c == "" {
+			s += " |"
+			continue
+		}
+		s += " " + cell(c) + " |"
+	}
+	return s
+}
+
+// cell escapes s for a table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func bold(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "**" + s + "**"
+}
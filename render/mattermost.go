@@ -0,0 +1,115 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Colors of the bars beside Mattermost attachments.
+const (
+	mattermostWeatherColor  = "#1e88e5"
+	mattermostSevereColor   = "#d32f2f"
+	mattermostModerateColor = "#f57c00"
+	mattermostMinorColor    = "#fbc02d"
+)
+
+// Mattermost renders weather as the attachments of a Mattermost message
+// payload, for webhooks and bots.
+type Mattermost struct{}
+
+var _ Renderer = Mattermost{}
+
+type mattermostField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type mattermostAttachment struct {
+	Fallback string             `json:"fallback"`
+	Color    string             `json:"color"`
+	Title    string             `json:"title"`
+	Text     string             `json:"text,omitempty"`
+	Fields   []*mattermostField `json:"fields,omitempty"`
+	Footer   string             `json:"footer,omitempty"`
+}
+
+type mattermostMessage struct {
+	Attachments []*mattermostAttachment `json:"attachments"`
+}
+
+func (Mattermost) Render(w io.Writer, weather *Weather) error {
+	m := &mattermostMessage{Attachments: []*mattermostAttachment{}}
+
+	if o := weather.Observation; o != nil {
+		a := &mattermostAttachment{
+			Fallback: fmt.Sprintf("%s: %s", o.Location, o.Title()),
+			Color:    mattermostWeatherColor,
+			Title:    "Current conditions in " + o.Location,
+			Text:     headline(o.Title(), o.Conditions),
+		}
+		for _, f := range o.Fields {
+			a.Fields = append(a.Fields, &mattermostField{Title: f.Label, Value: f.Value, Short: true})
+		}
+		footer := []string{}
+		if o.Station != "" {
+			footer = append(footer, o.Station)
+		}
+		if o.Time != nil {
+			footer = append(footer, "Observed "+o.Time.Format(TimeFormat))
+		}
+		a.Footer = strings.Join(footer, " · ")
+		m.Attachments = append(m.Attachments, a)
+	}
+
+	if f := weather.Forecast; f != nil {
+		a := &mattermostAttachment{
+			Fallback: fmt.Sprintf("%s for %s", f.Title, f.Location),
+			Color:    mattermostWeatherColor,
+			Title:    fmt.Sprintf("%s for %s", f.Title, f.Location),
+		}
+		for _, p := range f.Periods {
+			lines := []string{headline(p.Title(), p.Conditions)}
+			for _, field := range p.Fields {
+				lines = append(lines, fmt.Sprintf("%s: %s", field.Label, field.Value))
+			}
+			a.Fields = append(a.Fields, &mattermostField{Title: p.Name, Value: strings.Join(lines, "\n"), Short: true})
+		}
+		m.Attachments = append(m.Attachments, a)
+	}
+
+	for _, alert := range weather.Alerts {
+		text := []string{}
+		if alert.Headline != "" {
+			text = append(text, "**"+alert.Headline+"**")
+		}
+		for _, s := range []string{alert.Description, alert.Instruction} {
+			if s != "" {
+				text = append(text, s)
+			}
+		}
+		m.Attachments = append(m.Attachments, &mattermostAttachment{
+			Fallback: alertTitle(alert),
+			Color:    severityColor(alert.Severity),
+			Title:    warning + " " + alertTitle(alert),
+			Text:     strings.Join(text, "\n\n"),
+			Footer:   alert.Sender,
+		})
+	}
+	return json.NewEncoder(w).Encode(m)
+}
+
+// severityColor returns the color for an alert of severity, using the
+// Common Alerting Protocol's severities.
+func severityColor(severity string) string {
+	switch strings.ToLower(severity) {
+	case "extreme", "severe":
+		return mattermostSevereColor
+	case "moderate":
+		return mattermostModerateColor
+	default:
+		return mattermostMinorColor
+	}
+}
@@ -0,0 +1,207 @@
+// Package render formats observations, forecasts and alerts from any
+// provider for chat: as plain text, a Markdown table, JSON, Slack blocks,
+// Mattermost attachments or a user-supplied template.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/pkg/errors"
+)
+
+// TimeFormat is how renderers write times.
+const TimeFormat = "Mon Jan 2 3:04 PM MST"
+
+// warning marks alerts.
+const warning = "⚠️"
+
+// At returns a pointer to t for the times of observations, periods and
+// alerts, or nil if t is zero and the time is unknown.
+func At(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Field is a labeled value that's already formatted with its units, like
+// Temperature: 91 °F.
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Observation is the weather at a place and time.
+type Observation struct {
+	Location   string            `json:"location"`
+	Station    string            `json:"station,omitempty"`
+	Time       *time.Time        `json:"time,omitempty"`
+	Conditions conditions.Report `json:"conditions"`
+	// Description is the provider's own words for the conditions, if they
+	// should be used instead of the conditions' name.
+	Description string  `json:"description,omitempty"`
+	Fields      []Field `json:"fields"`
+}
+
+// Title describes the conditions.
+func (o *Observation) Title() string {
+	return title(o.Description, o.Conditions)
+}
+
+// Period is the forecast for an hour, a day or another named period.
+type Period struct {
+	Name        string            `json:"name"`
+	Time        *time.Time        `json:"time,omitempty"`
+	Conditions  conditions.Report `json:"conditions"`
+	Description string            `json:"description,omitempty"`
+	Fields      []Field           `json:"fields"`
+}
+
+// Title describes the conditions.
+func (p *Period) Title() string {
+	return title(p.Description, p.Conditions)
+}
+
+// Forecast is a series of forecast periods at a place.
+type Forecast struct {
+	Title    string    `json:"title"`
+	Location string    `json:"location"`
+	Periods  []*Period `json:"periods"`
+}
+
+// labels returns the labels of the fields of every period, in the order
+// they first appear.
+func (f *Forecast) labels() []string {
+	labels := []string{}
+	seen := map[string]bool{}
+	for _, p := range f.Periods {
+		for _, field := range p.Fields {
+			if !seen[field.Label] {
+				seen[field.Label] = true
+				labels = append(labels, field.Label)
+			}
+		}
+	}
+	return labels
+}
+
+// Alert is a watch, warning or advisory.
+type Alert struct {
+	Event       string     `json:"event"`
+	Severity    string     `json:"severity,omitempty"`
+	Headline    string     `json:"headline,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Instruction string     `json:"instruction,omitempty"`
+	Sender      string     `json:"sender,omitempty"`
+	Onset       *time.Time `json:"onset,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty"`
+}
+
+// Weather is everything a message can show. Any part may be missing.
+type Weather struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Forecast    *Forecast    `json:"forecast,omitempty"`
+	Alerts      []*Alert     `json:"alerts,omitempty"`
+}
+
+// Renderer writes weather in some format.
+type Renderer interface {
+	Render(w io.Writer, weather *Weather) error
+}
+
+// RendererFunc is a function that renders weather.
+type RendererFunc func(w io.Writer, weather *Weather) error
+
+func (f RendererFunc) Render(w io.Writer, weather *Weather) error {
+	return f(w, weather)
+}
+
+// String renders weather with r.
+func String(r Renderer, weather *Weather) (string, error) {
+	buffer := new(bytes.Buffer)
+	if err := r.Render(buffer, weather); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+var (
+	mu        sync.RWMutex
+	renderers = map[string]Renderer{
+		"text":       Text{},
+		"markdown":   Markdown{},
+		"json":       JSON{},
+		"slack":      Slack{},
+		"mattermost": Mattermost{},
+	}
+)
+
+// Register makes a renderer available to Lookup by name, replacing any
+// renderer already registered with that name.
+func Register(name string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	renderers[strings.ToLower(name)] = r
+}
+
+// Lookup returns the renderer registered as name, like "markdown".
+func Lookup(name string) (Renderer, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if r, ok := renderers[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return r, nil
+	}
+	return nil, errors.Errorf("unknown renderer '%s'; expected one of %s", name, strings.Join(names(), ", "))
+}
+
+// Names returns the names of the registered renderers, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	names := []string{}
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func title(description string, report conditions.Report) string {
+	if description != "" {
+		return description
+	}
+	return report.String()
+}
+
+// headline is the emoji and title of conditions, like "⛅ Partly Cloudy".
+func headline(title string, report conditions.Report) string {
+	if report.Condition == conditions.Unknown {
+		return title
+	}
+	return report.Emoji() + " " + title
+}
+
+// writer remembers the first error writing to w, so renderers can check
+// once at the end.
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
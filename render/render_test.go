@@ -0,0 +1,163 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWeather() *Weather {
+	cdt := time.FixedZone("CDT", -5*60*60)
+	return &Weather{
+		Observation: &Observation{
+			Location:   "Austin, TX",
+			Station:    "Camp Mabry",
+			Time:       At(time.Date(2020, 7, 4, 12, 0, 0, 0, cdt)),
+			Conditions: conditions.Report{Condition: conditions.PartlyCloudy},
+			Fields: []Field{
+				{Label: "Temperature", Value: "91 °F"},
+				{Label: "Humidity", Value: "52%"},
+				{Label: "Wind", Value: "8 mph"},
+			},
+		},
+		Forecast: &Forecast{
+			Title:    "Hourly Forecast",
+			Location: "Austin, TX",
+			Periods: []*Period{
+				{Name: "Sat 1 PM", Conditions: conditions.Report{Condition: conditions.LightRain},
+					Fields: []Field{{Label: "Temperature", Value: "92 °F"}, {Label: "Precipitation", Value: "40%"}}},
+				{Name: "Sat 9 PM", Conditions: conditions.Report{Condition: conditions.Clear, Night: true},
+					Fields: []Field{{Label: "Temperature", Value: "80 °F"}}},
+			},
+		},
+		Alerts: []*Alert{{
+			Event:       "Heat Advisory",
+			Severity:    "Moderate",
+			Headline:    "Heat Advisory until 8 PM",
+			Description: "Heat index values up to 108.",
+			Expires:     At(time.Date(2020, 7, 4, 20, 0, 0, 0, cdt)),
+		}},
+	}
+}
+
+func TestText(t *testing.T) {
+	s, err := String(Text{}, testWeather())
+	require.NoError(t, err)
+	assert.Contains(t, s, "Current conditions for Austin, TX: Partly Cloudy\nTemperature: 91 °F\n")
+	assert.Contains(t, s, "Observed: Sat Jul 4 12:00 PM CDT\n")
+	assert.Contains(t, s, "Sat 1 PM: Light Rain; Temperature 92 °F; Precipitation 40%\n")
+	assert.Contains(t, s, "\nHeat Advisory (Moderate) until Sat Jul 4 8:00 PM CDT\nHeat Advisory until 8 PM\n")
+}
+
+func TestMarkdown(t *testing.T) {
+	s, err := String(Markdown{}, testWeather())
+	require.NoError(t, err)
+	assert.Contains(t, s, "| Current Conditions | ⛅ Partly Cloudy | Location | Austin, TX |\n| :--- | ---: | :--- | ---: |\n")
+	assert.Contains(t, s, "| Temperature | 91 °F | Humidity | 52% |\n| Wind | 8 mph | Station | Camp Mabry |\n")
+	assert.Contains(t, s, "| Observed | Sat Jul 4 12:00 PM CDT | | |\n")
+	assert.Contains(t, s, "| Hourly Forecast | Austin, TX | | |\n| Period | Conditions | Temperature | Precipitation |\n")
+	assert.Contains(t, s, "| Sat 9 PM | 🌙 Clear | 80 °F | |\n")
+	assert.Contains(t, s, "#### ⚠️ Heat Advisory (Moderate) until Sat Jul 4 8:00 PM CDT\n**Heat Advisory until 8 PM**\n")
+
+	s, err = String(Markdown{}, &Weather{Observation: &Observation{Location: "A | B"}})
+	require.NoError(t, err)
+	assert.Contains(t, s, `| Location | A \| B |`)
+}
+
+func TestJSON(t *testing.T) {
+	s, err := String(JSON{}, testWeather())
+	require.NoError(t, err)
+	w := new(Weather)
+	require.NoError(t, json.Unmarshal([]byte(s), w))
+	assert.Equal(t, testWeather().Forecast, w.Forecast)
+	assert.Contains(t, s, `"condition": "partly-cloudy"`)
+	assert.Contains(t, s, `"time": "2020-07-04T12:00:00-05:00"`)
+	assert.NotContains(t, s, "0001-01-01", "unknown times are left out")
+}
+
+func TestSlack(t *testing.T) {
+	s, err := String(Slack{}, testWeather())
+	require.NoError(t, err)
+	m := new(slackMessage)
+	require.NoError(t, json.Unmarshal([]byte(s), m))
+	assert.Equal(t, "Austin, TX: Partly Cloudy; Hourly Forecast for Austin, TX; Heat Advisory (Moderate) until Sat Jul 4 8:00 PM CDT", m.Text)
+	types := []string{}
+	for _, b := range m.Blocks {
+		types = append(types, b.Type)
+	}
+	assert.Equal(t, []string{"header", "section", "context", "divider", "header", "section", "divider", "section"}, types)
+	assert.Equal(t, "*Temperature*\n91 °F", m.Blocks[1].Fields[0].Text)
+	assert.Equal(t, "*Sat 1 PM* 🌦️ Light Rain · Temperature 92 °F · Precipitation 40%\n*Sat 9 PM* 🌙 Clear · Temperature 80 °F",
+		m.Blocks[5].Text.Text)
+
+	fields := []Field{}
+	for i := 0; i < 15; i++ {
+		fields = append(fields, Field{Label: "x", Value: strings.Repeat("y", 4000)})
+	}
+	s, err = String(Slack{}, &Weather{Observation: &Observation{Location: "Austin", Fields: fields}})
+	require.NoError(t, err)
+	m = new(slackMessage)
+	require.NoError(t, json.Unmarshal([]byte(s), m))
+	assert.Len(t, m.Blocks[1].Fields, 10, "sections have at most 10 fields")
+	assert.Len(t, m.Blocks[2].Fields, 5)
+	assert.Len(t, []rune(m.Blocks[1].Fields[0].Text), 2000, "Slack limits field text to 2000 characters")
+}
+
+func TestMattermost(t *testing.T) {
+	s, err := String(Mattermost{}, testWeather())
+	require.NoError(t, err)
+	m := new(mattermostMessage)
+	require.NoError(t, json.Unmarshal([]byte(s), m))
+	require.Len(t, m.Attachments, 3)
+	assert.Equal(t, "Current conditions in Austin, TX", m.Attachments[0].Title)
+	assert.Equal(t, &mattermostField{Title: "Temperature", Value: "91 °F", Short: true}, m.Attachments[0].Fields[0])
+	assert.Equal(t, "Camp Mabry · Observed Sat Jul 4 12:00 PM CDT", m.Attachments[0].Footer)
+	assert.Equal(t, "🌦️ Light Rain\nTemperature: 92 °F\nPrecipitation: 40%", m.Attachments[1].Fields[0].Value)
+	assert.Equal(t, mattermostModerateColor, m.Attachments[2].Color)
+	assert.Equal(t, "**Heat Advisory until 8 PM**\n\nHeat index values up to 108.", m.Attachments[2].Text)
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := NewTemplate("short", `{{with .Observation}}{{emoji .Conditions}} {{.Title}} in {{.Location}} at {{time .Time}}{{end}}`+
+		`{{range .Alerts}} | {{.Event}}{{end}}`)
+	require.NoError(t, err)
+	s, err := String(tmpl, testWeather())
+	require.NoError(t, err)
+	assert.Equal(t, "⛅ Partly Cloudy in Austin, TX at Sat Jul 4 12:00 PM CDT | Heat Advisory", s)
+
+	_, err = NewTemplate("broken", "{{.Observation")
+	assert.Error(t, err)
+}
+
+func TestLookup(t *testing.T) {
+	r, err := Lookup(" Markdown ")
+	require.NoError(t, err)
+	assert.Equal(t, Markdown{}, r)
+
+	_, err = Lookup("html")
+	require.Error(t, err)
+	assert.Equal(t, "unknown renderer 'html'; expected one of json, markdown, mattermost, slack, text", err.Error())
+
+	Register("shout", RendererFunc(func(w io.Writer, weather *Weather) error {
+		_, err := io.WriteString(w, strings.ToUpper(weather.Observation.Location))
+		return err
+	}))
+	defer func() {
+		mu.Lock()
+		delete(renderers, "shout")
+		mu.Unlock()
+	}()
+	r, err = Lookup("shout")
+	require.NoError(t, err)
+	buffer := new(bytes.Buffer)
+	require.NoError(t, r.Render(buffer, testWeather()))
+	assert.Equal(t, "AUSTIN, TX", buffer.String())
+	assert.Contains(t, Names(), "shout")
+}
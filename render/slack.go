@@ -0,0 +1,137 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Slack limits the blocks of a message and the text in them.
+const (
+	slackMaxBlocks       = 50
+	slackMaxFields       = 10
+	slackMaxText         = 3000
+	slackMaxFieldText    = 2000
+	slackMaxHeader       = 150
+	slackPeriodsPerBlock = 10
+)
+
+// Slack renders weather as a Slack Block Kit message payload.
+type Slack struct{}
+
+var _ Renderer = Slack{}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string       `json:"type"`
+	Text     *slackText   `json:"text,omitempty"`
+	Fields   []*slackText `json:"fields,omitempty"`
+	Elements []*slackText `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	// Text is shown in notifications.
+	Text   string        `json:"text"`
+	Blocks []*slackBlock `json:"blocks"`
+}
+
+func plainText(s string) *slackText {
+	return &slackText{Type: "plain_text", Text: truncate(s, slackMaxHeader)}
+}
+
+func mrkdwn(s string) *slackText {
+	return &slackText{Type: "mrkdwn", Text: truncate(s, slackMaxText)}
+}
+
+// fieldText is the text of one of a section's fields, which Slack limits
+// more than other text.
+func fieldText(s string) *slackText {
+	return &slackText{Type: "mrkdwn", Text: truncate(s, slackMaxFieldText)}
+}
+
+func (Slack) Render(w io.Writer, weather *Weather) error {
+	m := &slackMessage{Blocks: []*slackBlock{}}
+	add := func(blocks ...*slackBlock) {
+		if len(m.Blocks) > 0 && len(blocks) > 0 {
+			blocks = append([]*slackBlock{{Type: "divider"}}, blocks...)
+		}
+		m.Blocks = append(m.Blocks, blocks...)
+	}
+	summaries := []string{}
+
+	if o := weather.Observation; o != nil {
+		summaries = append(summaries, fmt.Sprintf("%s: %s", o.Location, o.Title()))
+		blocks := []*slackBlock{{Type: "header", Text: plainText("Current conditions in " + o.Location)}}
+		fields := []*slackText{}
+		for _, f := range o.Fields {
+			fields = append(fields, fieldText(fmt.Sprintf("*%s*\n%s", f.Label, f.Value)))
+		}
+		text := mrkdwn("*" + headline(o.Title(), o.Conditions) + "*")
+		for len(fields) > slackMaxFields {
+			blocks = append(blocks, &slackBlock{Type: "section", Text: text, Fields: fields[:slackMaxFields]})
+			fields, text = fields[slackMaxFields:], nil
+		}
+		blocks = append(blocks, &slackBlock{Type: "section", Text: text, Fields: fields})
+		context := []string{}
+		if o.Station != "" {
+			context = append(context, o.Station)
+		}
+		if o.Time != nil {
+			context = append(context, "Observed "+o.Time.Format(TimeFormat))
+		}
+		if len(context) > 0 {
+			blocks = append(blocks, &slackBlock{Type: "context", Elements: []*slackText{mrkdwn(strings.Join(context, " · "))}})
+		}
+		add(blocks...)
+	}
+
+	if f := weather.Forecast; f != nil {
+		summaries = append(summaries, fmt.Sprintf("%s for %s", f.Title, f.Location))
+		blocks := []*slackBlock{{Type: "header", Text: plainText(fmt.Sprintf("%s for %s", f.Title, f.Location))}}
+		lines := []string{}
+		for i, p := range f.Periods {
+			lines = append(lines, fmt.Sprintf("*%s* %s", p.Name,
+				periodSummary(headline(p.Title(), p.Conditions), p.Fields, " · ")))
+			if len(lines) == slackPeriodsPerBlock || i == len(f.Periods)-1 {
+				blocks = append(blocks, &slackBlock{Type: "section", Text: mrkdwn(strings.Join(lines, "\n"))})
+				lines = []string{}
+			}
+		}
+		add(blocks...)
+	}
+
+	for _, a := range weather.Alerts {
+		summaries = append(summaries, alertTitle(a))
+		text := []string{fmt.Sprintf("%s *%s*", warning, alertTitle(a))}
+		if a.Headline != "" {
+			text = append(text, "*"+a.Headline+"*")
+		}
+		for _, s := range []string{a.Description, a.Instruction} {
+			if s != "" {
+				text = append(text, s)
+			}
+		}
+		add(&slackBlock{Type: "section", Text: mrkdwn(strings.Join(text, "\n"))})
+	}
+
+	if len(m.Blocks) > slackMaxBlocks {
+		m.Blocks = m.Blocks[:slackMaxBlocks]
+	}
+	m.Text = strings.Join(summaries, "; ")
+	return json.NewEncoder(w).Encode(m)
+}
+
+// truncate shortens s to at most n characters, ending with an ellipsis if
+// it had to cut it.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
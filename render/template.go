@@ -0,0 +1,48 @@
+package render
+
+import (
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gigawhitlocks/weather/conditions"
+)
+
+// Template renders weather with a user-supplied text/template. Besides the
+// standard functions, templates can use:
+//
+//	emoji     the emoji for a conditions.Report
+//	icon      the icon name for a conditions.Report
+//	time      a time formatted with TimeFormat, or "" if it's unknown
+//	join      strings joined with a separator
+type Template struct {
+	*template.Template
+}
+
+var _ Renderer = &Template{}
+
+var templateFuncs = template.FuncMap{
+	"emoji": func(r conditions.Report) string { return r.Emoji() },
+	"icon":  func(r conditions.Report) string { return r.IconName() },
+	"time": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(TimeFormat)
+	},
+	"join": func(sep string, s []string) string { return strings.Join(s, sep) },
+}
+
+// NewTemplate parses text as a template of a *Weather.
+func NewTemplate(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{Template: t}, nil
+}
+
+func (t *Template) Render(w io.Writer, weather *Weather) error {
+	return t.Execute(w, weather)
+}
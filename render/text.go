@@ -0,0 +1,74 @@
+package render
+
+import (
+	"io"
+	"strings"
+)
+
+// Text renders weather as plain text, one value to a line.
+type Text struct{}
+
+var _ Renderer = Text{}
+
+func (Text) Render(w io.Writer, weather *Weather) error {
+	out := &writer{w: w}
+	sections := 0
+	section := func() {
+		if sections > 0 {
+			out.printf("\n")
+		}
+		sections++
+	}
+	if o := weather.Observation; o != nil {
+		section()
+		out.printf("Current conditions for %s: %s\n", o.Location, o.Title())
+		for _, f := range o.Fields {
+			out.printf("%s: %s\n", f.Label, f.Value)
+		}
+		if o.Station != "" {
+			out.printf("Station: %s\n", o.Station)
+		}
+		if o.Time != nil {
+			out.printf("Observed: %s\n", o.Time.Format(TimeFormat))
+		}
+	}
+	if f := weather.Forecast; f != nil {
+		section()
+		out.printf("%s for %s:\n", f.Title, f.Location)
+		for _, p := range f.Periods {
+			out.printf("%s: %s\n", p.Name, periodSummary(p.Title(), p.Fields, "; "))
+		}
+	}
+	for _, a := range weather.Alerts {
+		section()
+		out.printf("%s\n", alertTitle(a))
+		for _, s := range []string{a.Headline, a.Description, a.Instruction} {
+			if s != "" {
+				out.printf("%s\n", s)
+			}
+		}
+	}
+	return out.err
+}
+
+// periodSummary is the title and fields of a period, separated by sep.
+func periodSummary(title string, fields []Field, sep string) string {
+	parts := []string{title}
+	for _, f := range fields {
+		parts = append(parts, f.Label+" "+f.Value)
+	}
+	return strings.Join(parts, sep)
+}
+
+// alertTitle is the event, severity and expiry of an alert, like "Flood
+// Warning (Severe) until Sat Jul 4 6:00 PM CDT".
+func alertTitle(a *Alert) string {
+	s := a.Event
+	if a.Severity != "" {
+		s += " (" + a.Severity + ")"
+	}
+	if a.Expires != nil {
+		s += " until " + a.Expires.Format(TimeFormat)
+	}
+	return s
+}